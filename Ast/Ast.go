@@ -4,6 +4,7 @@ import (
 	"Chimp/Token"
 	"bytes"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 )
@@ -40,9 +41,12 @@ type Expression interface {
 	ToString() string
 }
 
+// IntegerExpression is an integer literal. Big holds the value instead of Value
+// when it does not fit in an int64.
 type IntegerExpression struct {
 	Token Token.Token
 	Value int64
+	Big   *big.Int
 }
 
 func (ie IntegerExpression) TokenLiteral() string { return ie.Token.Literal }
@@ -51,6 +55,28 @@ func (ie IntegerExpression) ToString() string {
 	return ie.Token.Literal
 }
 
+type FloatExpression struct {
+	Token Token.Token
+	Value float64
+}
+
+func (fe FloatExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe FloatExpression) expressionNode()      {}
+func (fe FloatExpression) ToString() string {
+	return fe.Token.Literal
+}
+
+type StringExpression struct {
+	Token Token.Token
+	Value string
+}
+
+func (se StringExpression) TokenLiteral() string { return se.Token.Literal }
+func (se StringExpression) expressionNode()      {}
+func (se StringExpression) ToString() string {
+	return strconv.Quote(se.Value)
+}
+
 type BoolExpression struct {
 	Token Token.Token
 	Value bool
@@ -101,6 +127,39 @@ func (ie IdentityExpression) ToString() string {
 	return fmt.Sprintf("%s", ie.Value, )
 }
 
+// AssignExpression rebinds an existing name. Operator is "=" or a compound
+// assignment such as "+=".
+type AssignExpression struct {
+	Token    Token.Token
+	Name     IdentityExpression
+	Operator string
+	Value    Expression
+}
+
+func (ae AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae AssignExpression) expressionNode()      {}
+func (ae AssignExpression) ToString() string {
+	return fmt.Sprintf("%s %s %s", ae.Name.Value, ae.Operator, ae.Value.ToString())
+}
+
+// UpdateExpression increments or decrements a name with "++" or "--", evaluating to
+// the new value when Prefix and to the old one otherwise.
+type UpdateExpression struct {
+	Token    Token.Token
+	Name     IdentityExpression
+	Operator string
+	Prefix   bool
+}
+
+func (ue UpdateExpression) TokenLiteral() string { return ue.Token.Literal }
+func (ue UpdateExpression) expressionNode()      {}
+func (ue UpdateExpression) ToString() string {
+	if ue.Prefix {
+		return fmt.Sprintf("(%s%s)", ue.Operator, ue.Name.Value)
+	}
+	return fmt.Sprintf("(%s%s)", ue.Name.Value, ue.Operator)
+}
+
 type ExpressionStatement struct {
 	Token Token.Token
 	Value Expression
@@ -112,10 +171,13 @@ func (ls ExpressionStatement) ToString() string {
 	return ls.Value.ToString()
 }
 
+// LetStatement binds Name to Value. Doc holds the "///" doc comment written above
+// the statement, if any.
 type LetStatement struct {
 	Token Token.Token
 	Name  IdentityExpression
 	Value Expression
+	Doc   string
 }
 
 func (ls LetStatement) TokenLiteral() string { return ls.Token.Literal }
@@ -135,6 +197,8 @@ func (rs ReturnStatement) ToString() string {
 	return fmt.Sprintf("return %s", rs.Value.ToString())
 }
 
+// IfStatement is also an expression, evaluating to the value of the branch taken or
+// to null when no branch is.
 type IfStatement struct {
 	Token     Token.Token
 	Condition Expression
@@ -144,6 +208,7 @@ type IfStatement struct {
 
 func (is IfStatement) TokenLiteral() string { return is.Token.Literal }
 func (is IfStatement) statementNode()       {}
+func (is IfStatement) expressionNode()      {}
 func (is IfStatement) ToString() string {
 	res := fmt.Sprintf("if %s %s", is.Condition.ToString(), is.Then.ToString())
 	if is.Else.ToString() != "" {
@@ -152,6 +217,60 @@ func (is IfStatement) ToString() string {
 	return res
 }
 
+type WhileStatement struct {
+	Token     Token.Token
+	Condition Expression
+	Body      BlockStatement
+}
+
+func (ws WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws WhileStatement) statementNode()       {}
+func (ws WhileStatement) ToString() string {
+	return fmt.Sprintf("while %s %s", ws.Condition.ToString(), ws.Body.ToString())
+}
+
+// ForStatement is a C-style loop. Init, Condition and Post are nil when left out,
+// a missing Condition loops until a break or return.
+type ForStatement struct {
+	Token     Token.Token
+	Init      Statement
+	Condition Expression
+	Post      Statement
+	Body      BlockStatement
+}
+
+func (fs ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs ForStatement) statementNode()       {}
+func (fs ForStatement) ToString() string {
+	var init, condition, post string
+	if fs.Init != nil {
+		init = fs.Init.ToString()
+	}
+	if fs.Condition != nil {
+		condition = " " + fs.Condition.ToString()
+	}
+	if fs.Post != nil {
+		post = " " + fs.Post.ToString()
+	}
+	return fmt.Sprintf("for (%s;%s;%s) %s", init, condition, post, fs.Body.ToString())
+}
+
+type BreakStatement struct {
+	Token Token.Token
+}
+
+func (bs BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs BreakStatement) statementNode()       {}
+func (bs BreakStatement) ToString() string     { return "break" }
+
+type ContinueStatement struct {
+	Token Token.Token
+}
+
+func (cs ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs ContinueStatement) statementNode()       {}
+func (cs ContinueStatement) ToString() string     { return "continue" }
+
 type BlockStatement struct {
 	Token      Token.Token
 	Statements []Statement
@@ -172,6 +291,85 @@ func (bs BlockStatement) ToString() string {
 	return builder.String()
 }
 
+type ArrayExpression struct {
+	Token    Token.Token
+	Elements []Expression
+}
+
+func (ae ArrayExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae ArrayExpression) expressionNode()      {}
+func (ae ArrayExpression) ToString() string {
+	buffer := bytes.Buffer{}
+	for i, element := range ae.Elements {
+		buffer.WriteString(element.ToString())
+		if (i + 1) < len(ae.Elements) {
+			buffer.WriteString(", ")
+		}
+	}
+	return fmt.Sprintf("[%v]", buffer.String())
+}
+
+type IndexExpression struct {
+	Token  Token.Token
+	Target Expression
+	Index  Expression
+}
+
+func (ie IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie IndexExpression) expressionNode()      {}
+func (ie IndexExpression) ToString() string {
+	return fmt.Sprintf("(%s[%s])", ie.Target.ToString(), ie.Index.ToString())
+}
+
+type MapPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type MapExpression struct {
+	Token Token.Token
+	Pairs []MapPair
+}
+
+func (me MapExpression) TokenLiteral() string { return me.Token.Literal }
+func (me MapExpression) expressionNode()      {}
+func (me MapExpression) ToString() string {
+	buffer := bytes.Buffer{}
+	for i, pair := range me.Pairs {
+		buffer.WriteString(fmt.Sprintf("%s: %s", pair.Key.ToString(), pair.Value.ToString()))
+		if (i + 1) < len(me.Pairs) {
+			buffer.WriteString(", ")
+		}
+	}
+	return fmt.Sprintf("{%v}", buffer.String())
+}
+
+// MatchExpression evaluates to the body of the first arm whose pattern matches Value.
+type MatchExpression struct {
+	Token Token.Token
+	Value Expression
+	Arms  []MatchArm
+}
+
+// MatchArm pairs a pattern with the statement it selects. A pattern is a literal,
+// which matches an equal value, the wildcard "_", which matches anything, or an
+// identifier, which matches anything and binds it to that name. Body is a block or
+// an expression statement.
+type MatchArm struct {
+	Pattern Expression
+	Body    Statement
+}
+
+func (me MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me MatchExpression) expressionNode()      {}
+func (me MatchExpression) ToString() string {
+	arms := make([]string, 0, len(me.Arms))
+	for _, arm := range me.Arms {
+		arms = append(arms, fmt.Sprintf("%s => %s", arm.Pattern.ToString(), arm.Body.ToString()))
+	}
+	return fmt.Sprintf("match %s { %s }", me.Value.ToString(), strings.Join(arms, ", "))
+}
+
 type FunctionExpression struct {
 	Token      Token.Token
 	Parameters []IdentityExpression
@@ -0,0 +1,185 @@
+package Compiler
+
+import "Chimp/Ast"
+
+// capturedNames returns the locals of fn, its parameters and the names bound by its
+// let statements and match arms, that functions nested in it may refer to. The
+// analysis errs on the side of capturing: boxing a local that no closure shares
+// only costs a cell.
+func capturedNames(fn *Ast.FunctionExpression) map[string]bool {
+	names := analyseFunction(fn)
+
+	captured := map[string]bool{}
+	for name := range names.nested {
+		if names.declared[name] {
+			captured[name] = true
+		}
+	}
+	return captured
+}
+
+// letNames returns the names that fn's let statements bind in the scope of fn itself,
+// leaving out those of for loops and match arms and of the functions nested in fn.
+func letNames(fn *Ast.FunctionExpression) map[string]bool {
+	names := map[string]bool{}
+	var visit func(node Ast.Node) bool
+	visit = func(node Ast.Node) bool {
+		switch node := node.(type) {
+		case *Ast.FunctionExpression, *Ast.ForStatement:
+			return false
+		case *Ast.MatchExpression:
+			walk(node.Value, visit)
+			return false
+		case *Ast.LetStatement:
+			names[node.Name.Value] = true
+		}
+		return true
+	}
+	walk(fn.Body, visit)
+	return names
+}
+
+// assignsTo reports whether fn, or a function nested in it, assigns to name.
+func assignsTo(fn *Ast.FunctionExpression, name string) bool {
+	assigns := false
+	walk(fn.Body, func(node Ast.Node) bool {
+		switch node := node.(type) {
+		case *Ast.AssignExpression:
+			assigns = assigns || node.Name.Value == name
+		case *Ast.UpdateExpression:
+			assigns = assigns || node.Name.Value == name
+		}
+		return !assigns
+	})
+	return assigns
+}
+
+// functionNames are the names a function literal declares and refers to.
+type functionNames struct {
+	parameters map[string]bool
+	declared   map[string]bool
+	// used are the names the function itself refers to, nested the names that the
+	// functions nested in it refer to without declaring them as parameters.
+	used   map[string]bool
+	nested map[string]bool
+}
+
+// free returns the names the function may take from the scopes around it. Only
+// parameters are left out, as a let statement can follow a use of the outer name.
+func (f functionNames) free() map[string]bool {
+	free := map[string]bool{}
+	for _, names := range []map[string]bool{f.used, f.nested} {
+		for name := range names {
+			if !f.parameters[name] {
+				free[name] = true
+			}
+		}
+	}
+	return free
+}
+
+func analyseFunction(fn *Ast.FunctionExpression) functionNames {
+	names := functionNames{
+		parameters: map[string]bool{},
+		declared:   map[string]bool{},
+		used:       map[string]bool{},
+		nested:     map[string]bool{},
+	}
+	for _, p := range fn.Parameters {
+		names.parameters[p.Value] = true
+		names.declared[p.Value] = true
+	}
+
+	walk(fn.Body, func(node Ast.Node) bool {
+		switch node := node.(type) {
+		case *Ast.FunctionExpression:
+			for name := range analyseFunction(node).free() {
+				names.nested[name] = true
+			}
+			return false
+		case *Ast.LetStatement:
+			names.declared[node.Name.Value] = true
+		case *Ast.MatchExpression:
+			for _, arm := range node.Arms {
+				if binding, ok := arm.Pattern.(*Ast.IdentityExpression); ok {
+					names.declared[binding.Value] = true
+				}
+			}
+		case *Ast.IdentityExpression:
+			names.used[node.Value] = true
+		case *Ast.AssignExpression:
+			names.used[node.Name.Value] = true
+		case *Ast.UpdateExpression:
+			names.used[node.Name.Value] = true
+		}
+		return true
+	})
+	return names
+}
+
+// walk calls visit for node and, while visit returns true, for the nodes inside it.
+func walk(node Ast.Node, visit func(Ast.Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case Ast.Programme:
+		for _, statement := range node.Statements {
+			walk(statement, visit)
+		}
+	case Ast.BlockStatement:
+		for _, statement := range node.Statements {
+			walk(statement, visit)
+		}
+	case Ast.ExpressionStatement:
+		walk(node.Value, visit)
+	case *Ast.LetStatement:
+		walk(node.Value, visit)
+	case *Ast.ReturnStatement:
+		walk(node.Value, visit)
+	case Ast.IfStatement:
+		walk(node.Condition, visit)
+		walk(node.Then, visit)
+		walk(node.Else, visit)
+	case *Ast.MatchExpression:
+		walk(node.Value, visit)
+		for _, arm := range node.Arms {
+			walk(arm.Body, visit)
+		}
+	case *Ast.WhileStatement:
+		walk(node.Condition, visit)
+		walk(node.Body, visit)
+	case *Ast.ForStatement:
+		walk(node.Init, visit)
+		walk(node.Condition, visit)
+		walk(node.Post, visit)
+		walk(node.Body, visit)
+	case *Ast.AssignExpression:
+		walk(node.Value, visit)
+	case *Ast.PrefixExpression:
+		walk(node.Expression, visit)
+	case *Ast.InfixExpression:
+		walk(node.LeftExpression, visit)
+		walk(node.RightExpression, visit)
+	case *Ast.ArrayExpression:
+		for _, element := range node.Elements {
+			walk(element, visit)
+		}
+	case *Ast.MapExpression:
+		for _, pair := range node.Pairs {
+			walk(pair.Key, visit)
+			walk(pair.Value, visit)
+		}
+	case *Ast.IndexExpression:
+		walk(node.Target, visit)
+		walk(node.Index, visit)
+	case *Ast.FunctionExpression:
+		walk(node.Body, visit)
+	case *Ast.CallExpression:
+		walk(node.Target, visit)
+		for _, param := range node.Parameters {
+			walk(param, visit)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package Compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpDup
+	OpNull
+	OpTrue
+	OpFalse
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpGreaterEqual
+	OpLessThan
+	OpLessEqual
+	OpMinus
+	OpBang
+
+	OpJump
+	OpJumpNotTrue
+
+	// OpJumpIfFalse and OpJumpIfTrue short-circuit "&&" and "||", jumping with the
+	// left operand left as the result when it decides it and popping it otherwise.
+	OpJumpIfFalse
+	OpJumpIfTrue
+
+	// OpNoMatch fails a match expression with the value none of its arms matched.
+	OpNoMatch
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetFree
+	OpCurrentClosure
+
+	// Boxed locals hold a cell in their slot. OpCell pushes the cell itself, creating
+	// it if need be, for a closure to capture.
+	OpGetCell
+	OpSetCell
+	OpCell
+	OpGetFreeCell
+	OpSetFreeCell
+
+	OpArray
+	OpMap
+	OpIndex
+
+	OpClosure
+	OpCall
+	OpReturnValue
+)
+
+// Definition describes an opcode for encoding and disassembly.
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{}},
+	OpNull:     {"OpNull", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+
+	OpJump:        {"OpJump", []int{2}},
+	OpJumpNotTrue: {"OpJumpNotTrue", []int{2}},
+
+	OpJumpIfFalse: {"OpJumpIfFalse", []int{2}},
+	OpJumpIfTrue:  {"OpJumpIfTrue", []int{2}},
+
+	OpNoMatch: {"OpNoMatch", []int{}},
+
+	OpGetGlobal:      {"OpGetGlobal", []int{2}},
+	OpSetGlobal:      {"OpSetGlobal", []int{2}},
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+
+	OpGetCell:     {"OpGetCell", []int{1}},
+	OpSetCell:     {"OpSetCell", []int{1}},
+	OpCell:        {"OpCell", []int{1}},
+	OpGetFreeCell: {"OpGetFreeCell", []int{1}},
+	OpSetFreeCell: {"OpSetFreeCell", []int{1}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpMap:   {"OpMap", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+
+	OpClosure:     {"OpClosure", []int{2, 1}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+}
+
+// operandMeanings describes what each operand counts or indexes, for the error
+// reported when a programme needs an operand wider than its definition allows.
+var operandMeanings = map[Opcode][]string{
+	OpConstant: {"constants"},
+
+	OpJump:        {"bytes of code in a function or programme"},
+	OpJumpNotTrue: {"bytes of code in a function or programme"},
+	OpJumpIfFalse: {"bytes of code in a function or programme"},
+	OpJumpIfTrue:  {"bytes of code in a function or programme"},
+
+	OpGetGlobal:   {"global bindings"},
+	OpSetGlobal:   {"global bindings"},
+	OpGetLocal:    {"local bindings in a function"},
+	OpSetLocal:    {"local bindings in a function"},
+	OpGetFree:     {"variables captured by a function"},
+	OpGetCell:     {"local bindings in a function"},
+	OpSetCell:     {"local bindings in a function"},
+	OpCell:        {"local bindings in a function"},
+	OpGetFreeCell: {"variables captured by a function"},
+	OpSetFreeCell: {"variables captured by a function"},
+
+	OpArray: {"array elements"},
+	OpMap:   {"map keys and values"},
+
+	OpClosure: {"constants", "variables captured by a function"},
+	OpCall:    {"call arguments"},
+}
+
+// Operators maps the opcodes of infix and prefix operations to the operator they apply.
+var Operators = map[Opcode]string{
+	OpAdd:          "+",
+	OpSub:          "-",
+	OpMul:          "*",
+	OpDiv:          "/",
+	OpEqual:        "==",
+	OpNotEqual:     "!=",
+	OpGreaterThan:  ">",
+	OpGreaterEqual: ">=",
+	OpLessThan:     "<",
+	OpLessEqual:    "<=",
+	OpMinus:        "-",
+	OpBang:         "!",
+}
+
+func Lookup(op Opcode) (*Definition, error) {
+	def, ok := definitions[op]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction, operands are big-endian.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands following an opcode and returns how many bytes they span.
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return ins[0]
+}
+
+// String disassembles the instructions, one per line prefixed with its offset.
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(Opcode(ins[i]))
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		_, _ = fmt.Fprintf(&out, "%04d %s\n", i, formatInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func formatInstruction(def *Definition, operands []int) string {
+	switch len(operands) {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+	return fmt.Sprintf("ERROR: unhandled operand count for %s", def.Name)
+}
@@ -0,0 +1,739 @@
+package Compiler
+
+import (
+	"Chimp/Ast"
+	"Chimp/Evaluator"
+	"Chimp/Object"
+	"Chimp/Token"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SourceInfo ties an instruction to the source it was compiled from, so that the VM
+// can report runtime errors the way the Evaluator does.
+type SourceInfo struct {
+	Position Token.Position
+	// Name is the identifier loaded by an OpGetGlobal, or the called expression of an
+	// OpCall and of the load of its target.
+	Name string
+	// Callee marks the OpGetGlobal loading the target of a call.
+	Callee bool
+	// Assign marks the load that checks the target of an assignment is declared.
+	Assign bool
+}
+
+// SourceMap maps instruction offsets to their SourceInfo.
+type SourceMap map[int]SourceInfo
+
+// CompiledFunction is the constant a function literal compiles to. At run time the
+// VM wraps it in a closure together with its free variables.
+type CompiledFunction struct {
+	Instructions  Instructions
+	SourceMap     SourceMap
+	NumLocals     int
+	NumParameters int
+	// Source renders the function literal, so closures inspect like Object.Function.
+	Source string
+}
+
+func (cf *CompiledFunction) Type() Object.ObjectType { return Object.COMPILED_FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string         { return cf.Source }
+
+type Bytecode struct {
+	Instructions Instructions
+	SourceMap    SourceMap
+	Constants    []Object.Object
+	// Globals names the global slots by index.
+	Globals []string
+}
+
+// loop collects the jumps of the break and continue statements in a loop body until
+// the offsets they jump to are known. operands is the count of operands on the stack
+// when the loop starts, which the jumps leave behind.
+type loop struct {
+	breaks    []int
+	continues []int
+	operands  int
+}
+
+type compilationScope struct {
+	instructions Instructions
+	sourceMap    SourceMap
+}
+
+// Compiler lowers an Ast.Programme to Bytecode. Every statement compiles to code that
+// leaves exactly one value on the stack, the value Eval would produce for it, so a
+// block is its statements with all but the last value popped.
+type Compiler struct {
+	constants   []Object.Object
+	symbolTable *SymbolTable
+	scopes      []compilationScope
+	loops       []*loop
+	// operands counts the values that the expressions being compiled keep on the stack
+	// beneath the one being compiled, which a break or continue drops.
+	operands int
+	// err is the first operand that did not fit its width, which Compile reports.
+	err error
+}
+
+func New() *Compiler {
+	return &Compiler{
+		constants:   []Object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []compilationScope{{instructions: Instructions{}, sourceMap: SourceMap{}}},
+	}
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentScope().instructions,
+		SourceMap:    c.currentScope().sourceMap,
+		Constants:    c.constants,
+		Globals:      c.symbolTable.Global().Names(),
+	}
+}
+
+// Compile compiles node, failing when the programme is too large for the bytecode,
+// such as a function with more locals than an operand can index.
+func (c *Compiler) Compile(node Ast.Node) error {
+	if err := c.compile(node); err != nil {
+		return err
+	}
+	return c.err
+}
+
+func (c *Compiler) compile(node Ast.Node) error {
+	switch node := node.(type) {
+	case Ast.Programme:
+		return c.compileStatements(node.Statements)
+	case Ast.BlockStatement:
+		return c.compileStatements(node.Statements)
+	case Ast.ExpressionStatement:
+		return c.Compile(node.Value)
+	case *Ast.LetStatement:
+		return c.compileLet(node)
+	case *Ast.ReturnStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(OpReturnValue)
+	case Ast.IfStatement:
+		return c.compileIf(node)
+	case *Ast.MatchExpression:
+		return c.compileMatch(node)
+	case *Ast.WhileStatement:
+		return c.compileLoop(nil, node.Condition, nil, node.Body)
+	case *Ast.ForStatement:
+		c.enterBlock()
+		defer c.leaveBlock()
+		return c.compileLoop(node.Init, node.Condition, node.Post, node.Body)
+	case *Ast.BreakStatement:
+		current := c.leaveOperands()
+		current.breaks = append(current.breaks, c.emit(OpJump, 9999))
+	case *Ast.ContinueStatement:
+		current := c.leaveOperands()
+		current.continues = append(current.continues, c.emit(OpJump, 9999))
+	case *Ast.IdentityExpression:
+		c.compileIdentifier(node.Value, SourceInfo{Position: node.Token.Position, Name: node.Value})
+	case *Ast.AssignExpression:
+		return c.compileAssign(node)
+	case *Ast.UpdateExpression:
+		return c.compileUpdate(node)
+	case *Ast.IntegerExpression:
+		if node.Big != nil {
+			c.emit(OpConstant, c.addConstant(Object.BigInteger{Value: node.Big}))
+		} else {
+			c.emit(OpConstant, c.addConstant(Object.Integer{Value: node.Value}))
+		}
+	case *Ast.FloatExpression:
+		c.emit(OpConstant, c.addConstant(Object.Float{Value: node.Value}))
+	case *Ast.StringExpression:
+		c.emit(OpConstant, c.addConstant(Object.String{Value: node.Value}))
+	case *Ast.BoolExpression:
+		if node.Value {
+			c.emit(OpTrue)
+		} else {
+			c.emit(OpFalse)
+		}
+	case *Ast.PrefixExpression:
+		return c.compilePrefix(node)
+	case *Ast.InfixExpression:
+		return c.compileInfix(node)
+	case *Ast.ArrayExpression:
+		if err := c.compileOperands(node.Elements...); err != nil {
+			return err
+		}
+		c.emit(OpArray, len(node.Elements))
+	case *Ast.MapExpression:
+		var operands []Ast.Expression
+		for _, pair := range node.Pairs {
+			operands = append(operands, pair.Key, pair.Value)
+		}
+		if err := c.compileOperands(operands...); err != nil {
+			return err
+		}
+		c.emitAt(SourceInfo{Position: node.Token.Position}, OpMap, len(node.Pairs)*2)
+	case *Ast.IndexExpression:
+		if err := c.compileOperands(node.Target, node.Index); err != nil {
+			return err
+		}
+		c.emitAt(SourceInfo{Position: node.Token.Position}, OpIndex)
+	case *Ast.FunctionExpression:
+		return c.compileFunction(node, "")
+	case *Ast.CallExpression:
+		return c.compileCall(node)
+	default:
+		return fmt.Errorf("cannot compile node of type %T", node)
+	}
+
+	return nil
+}
+
+// compileOperands compiles expressions in turn, each value staying on the stack
+// beneath those of the expressions after it.
+func (c *Compiler) compileOperands(expressions ...Ast.Expression) error {
+	defer func(operands int) { c.operands = operands }(c.operands)
+	for _, expression := range expressions {
+		if err := c.Compile(expression); err != nil {
+			return err
+		}
+		c.operands++
+	}
+	return nil
+}
+
+// leaveOperands pops the operands that the expressions around a break or continue
+// have pushed since the loop it leaves started, returning that loop.
+func (c *Compiler) leaveOperands() *loop {
+	current := c.loops[len(c.loops)-1]
+	for i := current.operands; i < c.operands; i++ {
+		c.emit(OpPop)
+	}
+	return current
+}
+
+func (c *Compiler) compileStatements(statements []Ast.Statement) error {
+	if len(statements) == 0 {
+		c.emit(OpNull)
+		return nil
+	}
+
+	for i, statement := range statements {
+		if err := c.Compile(statement); err != nil {
+			return err
+		}
+		if i+1 < len(statements) {
+			c.emit(OpPop)
+		}
+	}
+	return nil
+}
+
+// compileLet defines the name only after compiling the value, so that the value still
+// sees any outer binding of the same name, as it does in Eval.
+func (c *Compiler) compileLet(node *Ast.LetStatement) error {
+	var symbol Symbol
+	var err error
+	function, ok := node.Value.(*Ast.FunctionExpression)
+	switch {
+	case ok && assignsTo(function, node.Name.Value):
+		// a function that rebinds its own name refers to the binding, as in Eval,
+		// rather than to itself
+		symbol = c.symbolTable.Define(node.Name.Value)
+		err = c.compileFunction(function, "")
+	case ok:
+		err = c.compileFunction(function, node.Name.Value)
+	default:
+		err = c.Compile(node.Value)
+	}
+	if err != nil {
+		return err
+	}
+
+	if symbol.Name == "" {
+		symbol = c.symbolTable.Define(node.Name.Value)
+	}
+	c.storeSymbol(symbol)
+	c.loadValue(symbol, SourceInfo{Position: node.Name.Token.Position, Name: node.Name.Value})
+	return nil
+}
+
+// compileAssign first loads the current value of the target, which fails at run time
+// when the name was never bound, as it does in Eval.
+func (c *Compiler) compileAssign(node *Ast.AssignExpression) error {
+	symbol := c.assignmentTarget(node.Name)
+
+	info := SourceInfo{Position: node.Name.Token.Position, Name: node.Name.Value, Assign: true}
+	c.loadValue(symbol, info)
+
+	if node.Operator == "=" {
+		c.emit(OpPop)
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+	} else {
+		c.operands++
+		err := c.Compile(node.Value)
+		c.operands--
+		if err != nil {
+			return err
+		}
+		c.emitAt(SourceInfo{Position: node.Token.Position}, infixOpcodes[strings.TrimSuffix(node.Operator, "=")])
+	}
+
+	c.storeSymbol(symbol)
+	c.loadValue(symbol, info)
+	return nil
+}
+
+func (c *Compiler) compileUpdate(node *Ast.UpdateExpression) error {
+	symbol := c.assignmentTarget(node.Name)
+
+	info := SourceInfo{Position: node.Name.Token.Position, Name: node.Name.Value, Assign: true}
+	c.loadValue(symbol, info)
+	if !node.Prefix {
+		// the old value stays below as the result
+		c.loadValue(symbol, info)
+	}
+
+	c.emit(OpConstant, c.addConstant(Object.Integer{Value: 1}))
+	c.emitAt(SourceInfo{Position: node.Token.Position}, infixOpcodes[node.Operator[:1]])
+	c.storeSymbol(symbol)
+
+	if node.Prefix {
+		c.loadValue(symbol, info)
+	}
+	return nil
+}
+
+// assignmentTarget resolves the name an assignment rebinds. A name bound nowhere is
+// given a global slot, where loading it before the assignment fails as in Eval.
+func (c *Compiler) assignmentTarget(name Ast.IdentityExpression) Symbol {
+	symbol, ok := c.symbolTable.Resolve(name.Value)
+	if !ok {
+		symbol = c.symbolTable.Global().Define(name.Value)
+	}
+	return symbol
+}
+
+func (c *Compiler) compileIf(node Ast.IfStatement) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTrue := c.emit(OpJumpNotTrue, 9999)
+
+	if err := c.Compile(node.Then); err != nil {
+		return err
+	}
+
+	jump := c.emit(OpJump, 9999)
+
+	c.changeOperand(jumpNotTrue, len(c.currentScope().instructions))
+
+	if err := c.Compile(node.Else); err != nil {
+		return err
+	}
+
+	c.changeOperand(jump, len(c.currentScope().instructions))
+	return nil
+}
+
+// compileMatch keeps the value on the stack while it is compared with each literal
+// pattern in turn, popping it, or binding it like a let statement, once an arm is
+// chosen. The names an arm declares are scoped to it, as in Eval.
+func (c *Compiler) compileMatch(node *Ast.MatchExpression) error {
+	if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+
+	var exits []int
+	exhaustive := false
+	for _, arm := range node.Arms {
+		if identifier, ok := arm.Pattern.(*Ast.IdentityExpression); ok {
+			if err := c.compileArm(identifier, arm.Body); err != nil {
+				return err
+			}
+			// the arm matches anything, so later arms are unreachable
+			exhaustive = true
+			break
+		}
+
+		c.emit(OpDup)
+		if err := c.Compile(arm.Pattern); err != nil {
+			return err
+		}
+		c.emit(OpEqual)
+		next := c.emit(OpJumpNotTrue, 9999)
+
+		if err := c.compileArm(nil, arm.Body); err != nil {
+			return err
+		}
+		exits = append(exits, c.emit(OpJump, 9999))
+		c.changeOperand(next, len(c.currentScope().instructions))
+	}
+
+	if !exhaustive {
+		c.emitAt(SourceInfo{Position: node.Token.Position}, OpNoMatch)
+	}
+
+	for _, exit := range exits {
+		c.changeOperand(exit, len(c.currentScope().instructions))
+	}
+	return nil
+}
+
+// compileArm pops the matched value, binding it to binding unless that is nil or "_",
+// and compiles body within the scope of the arm.
+func (c *Compiler) compileArm(binding *Ast.IdentityExpression, body Ast.Statement) error {
+	c.enterBlock()
+	defer c.leaveBlock()
+
+	if binding == nil || binding.Value == "_" {
+		c.emit(OpPop)
+	} else {
+		c.storeSymbol(c.symbolTable.Define(binding.Value))
+	}
+	return c.Compile(body)
+}
+
+// compileLoop leaves null on the stack once the loop is done. Break and continue
+// statements jump without pushing a value, as every statement would, because the
+// code after them in their block is unreachable.
+func (c *Compiler) compileLoop(init Ast.Statement, condition Ast.Expression, post Ast.Statement, body Ast.BlockStatement) error {
+	if init != nil {
+		if err := c.Compile(init); err != nil {
+			return err
+		}
+		c.emit(OpPop)
+	}
+
+	start := len(c.currentScope().instructions)
+
+	exit := -1
+	if condition != nil {
+		if err := c.Compile(condition); err != nil {
+			return err
+		}
+		exit = c.emit(OpJumpNotTrue, 9999)
+	}
+
+	current := &loop{operands: c.operands}
+	c.loops = append(c.loops, current)
+	err := c.Compile(body)
+	c.loops = c.loops[:len(c.loops)-1]
+	if err != nil {
+		return err
+	}
+	c.emit(OpPop)
+
+	next := len(c.currentScope().instructions)
+	if post != nil {
+		if err := c.Compile(post); err != nil {
+			return err
+		}
+		c.emit(OpPop)
+	}
+	c.emit(OpJump, start)
+
+	end := len(c.currentScope().instructions)
+	if exit >= 0 {
+		c.changeOperand(exit, end)
+	}
+	for _, jump := range current.breaks {
+		c.changeOperand(jump, end)
+	}
+	for _, jump := range current.continues {
+		c.changeOperand(jump, next)
+	}
+
+	c.emit(OpNull)
+	return nil
+}
+
+// compileIdentifier loads name. Names that are not bound anywhere and are not builtins
+// become globals, which the VM reports as unknown if they are still unset when loaded.
+func (c *Compiler) compileIdentifier(name string, info SourceInfo) {
+	symbol, ok := c.symbolTable.Resolve(name)
+	if !ok {
+		if builtin, isBuiltin := Evaluator.LookupBuiltin(name); isBuiltin {
+			c.emit(OpConstant, c.addConstant(builtin))
+			return
+		}
+		symbol = c.symbolTable.Global().Define(name)
+	}
+
+	c.loadValue(symbol, info)
+}
+
+// loadValue loads the value bound to symbol, recording info for the loads that fail
+// when the name is not bound at run time.
+func (c *Compiler) loadValue(symbol Symbol, info SourceInfo) {
+	switch {
+	case symbol.Scope == GlobalScope:
+		c.emitAt(info, OpGetGlobal, symbol.Index)
+	case symbol.Scope == LocalScope && symbol.Boxed:
+		c.emitAt(info, OpGetCell, symbol.Index)
+	case symbol.Scope == LocalScope:
+		c.emitAt(info, OpGetLocal, symbol.Index)
+	case symbol.Scope == FreeScope && symbol.Boxed:
+		c.emitAt(info, OpGetFreeCell, symbol.Index)
+	case symbol.Scope == FreeScope:
+		c.emit(OpGetFree, symbol.Index)
+	case symbol.Scope == FunctionScope:
+		c.emit(OpCurrentClosure)
+	}
+}
+
+// storeSymbol binds the value on top of the stack to symbol. Free symbols that are
+// assigned to are always boxed, as the function that defines them captures them.
+func (c *Compiler) storeSymbol(symbol Symbol) {
+	switch {
+	case symbol.Scope == GlobalScope:
+		c.emit(OpSetGlobal, symbol.Index)
+	case symbol.Scope == LocalScope && symbol.Boxed:
+		c.emit(OpSetCell, symbol.Index)
+	case symbol.Scope == LocalScope:
+		c.emit(OpSetLocal, symbol.Index)
+	case symbol.Scope == FreeScope:
+		c.emit(OpSetFreeCell, symbol.Index)
+	}
+}
+
+func (c *Compiler) compilePrefix(node *Ast.PrefixExpression) error {
+	if err := c.Compile(node.Expression); err != nil {
+		return err
+	}
+
+	switch node.Operator {
+	case "-":
+		c.emitAt(SourceInfo{Position: node.Token.Position}, OpMinus)
+	case "!":
+		c.emitAt(SourceInfo{Position: node.Token.Position}, OpBang)
+	default:
+		return fmt.Errorf("%s: cannot compile prefix operator '%s'", node.Token.Position, node.Operator)
+	}
+	return nil
+}
+
+var infixOpcodes = map[string]Opcode{
+	"+":  OpAdd,
+	"-":  OpSub,
+	"*":  OpMul,
+	"/":  OpDiv,
+	"==": OpEqual,
+	"!=": OpNotEqual,
+	">":  OpGreaterThan,
+	">=": OpGreaterEqual,
+	"<":  OpLessThan,
+	"<=": OpLessEqual,
+}
+
+func (c *Compiler) compileInfix(node *Ast.InfixExpression) error {
+	if node.Operator == "&&" || node.Operator == "||" {
+		return c.compileLogical(node)
+	}
+
+	op, ok := infixOpcodes[node.Operator]
+	if !ok {
+		return fmt.Errorf("%s: cannot compile infix operator '%s'", node.Token.Position, node.Operator)
+	}
+
+	if err := c.compileOperands(node.LeftExpression, node.RightExpression); err != nil {
+		return err
+	}
+
+	c.emitAt(SourceInfo{Position: node.Token.Position}, op)
+	return nil
+}
+
+func (c *Compiler) compileLogical(node *Ast.InfixExpression) error {
+	if err := c.Compile(node.LeftExpression); err != nil {
+		return err
+	}
+
+	op := OpJumpIfFalse
+	if node.Operator == "||" {
+		op = OpJumpIfTrue
+	}
+	jump := c.emit(op, 9999)
+
+	if err := c.Compile(node.RightExpression); err != nil {
+		return err
+	}
+
+	c.changeOperand(jump, len(c.currentScope().instructions))
+	return nil
+}
+
+// compileFunction compiles a function literal into a closure. A function bound by a
+// let statement is given its name so that it can refer to itself.
+//
+// Closures share the locals they capture with the function that defines them, as
+// they share environments in Eval, by boxing those locals in cells. Which locals are
+// captured is found from the function's body before it is compiled.
+func (c *Compiler) compileFunction(node *Ast.FunctionExpression, name string) error {
+	c.enterScope()
+	captured := capturedNames(node)
+	for n := range captured {
+		c.symbolTable.boxed[n] = true
+	}
+
+	if name != "" {
+		c.symbolTable.DefineFunctionName(name)
+	}
+	for _, p := range node.Parameters {
+		if symbol := c.symbolTable.Define(p.Value); symbol.Boxed {
+			c.emit(OpGetLocal, symbol.Index)
+			c.emit(OpSetCell, symbol.Index)
+		}
+	}
+	// a closure can call a local that is bound after it is defined, as it looks the
+	// name up when called in Eval, so the captured locals are given slots up front
+	var hoisted []string
+	for n := range letNames(node) {
+		if captured[n] {
+			hoisted = append(hoisted, n)
+		}
+	}
+	sort.Strings(hoisted)
+	for _, n := range hoisted {
+		c.symbolTable.Define(n)
+	}
+
+	if err := c.compileStatements(node.Body.Statements); err != nil {
+		return err
+	}
+	c.emit(OpReturnValue)
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	scope := c.leaveScope()
+
+	for _, s := range freeSymbols {
+		c.loadSymbol(s)
+	}
+
+	compiledFunction := &CompiledFunction{
+		Instructions:  scope.instructions,
+		SourceMap:     scope.sourceMap,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Source:        functionSource(node),
+	}
+
+	c.emit(OpClosure, c.addConstant(compiledFunction), len(freeSymbols))
+	return nil
+}
+
+// functionSource renders a function literal the way Object.Function inspects.
+func functionSource(node *Ast.FunctionExpression) string {
+	var params []string
+	for _, p := range node.Parameters {
+		params = append(params, p.ToString())
+	}
+	return fmt.Sprintf("(%s) %s", strings.Join(params, ", "), node.Body.ToString())
+}
+
+// loadSymbol loads a symbol for a closure to capture, which is the cell of a boxed
+// symbol rather than its value.
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch {
+	case s.Scope == LocalScope && s.Boxed:
+		c.emit(OpCell, s.Index)
+	case s.Scope == LocalScope:
+		c.emit(OpGetLocal, s.Index)
+	case s.Scope == FreeScope:
+		c.emit(OpGetFree, s.Index)
+	case s.Scope == FunctionScope:
+		c.emit(OpCurrentClosure)
+	}
+}
+
+func (c *Compiler) compileCall(node *Ast.CallExpression) error {
+	callee := node.Target.ToString()
+
+	if identifier, ok := node.Target.(*Ast.IdentityExpression); ok {
+		c.compileIdentifier(identifier.Value, SourceInfo{Position: node.Token.Position, Name: callee, Callee: true})
+	} else if err := c.Compile(node.Target); err != nil {
+		return err
+	}
+
+	c.operands++
+	err := c.compileOperands(node.Parameters...)
+	c.operands--
+	if err != nil {
+		return err
+	}
+
+	c.emitAt(SourceInfo{Position: node.Token.Position, Name: callee}, OpCall, len(node.Parameters))
+	return nil
+}
+
+func (c *Compiler) addConstant(obj Object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := Make(op, operands...)
+	pos := len(c.currentScope().instructions)
+	c.scopes[len(c.scopes)-1].instructions = append(c.currentScope().instructions, ins...)
+	return pos
+}
+
+// emitAt emits an instruction that can fail at run time, recording where it came from.
+func (c *Compiler) emitAt(info SourceInfo, op Opcode, operands ...int) int {
+	pos := c.emit(op, operands...)
+	c.currentScope().sourceMap[pos] = info
+	return pos
+}
+
+func (c *Compiler) changeOperand(pos int, operand int) {
+	op := Opcode(c.currentScope().instructions[pos])
+	c.checkOperands(op, []int{operand})
+	ins := Make(op, operand)
+	copy(c.currentScope().instructions[pos:], ins)
+}
+
+// checkOperands records an error for the first operand too large for its width,
+// which Make would silently truncate.
+func (c *Compiler) checkOperands(op Opcode, operands []int) {
+	if c.err != nil {
+		return
+	}
+	for i, operand := range operands {
+		if limit := 1<<(8*definitions[op].OperandWidths[i]) - 1; operand > limit {
+			c.err = fmt.Errorf("too many %s: %d exceeds the limit of %d", operandMeanings[op][i], operand, limit)
+			return
+		}
+	}
+}
+
+func (c *Compiler) currentScope() compilationScope {
+	return c.scopes[len(c.scopes)-1]
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, compilationScope{instructions: Instructions{}, sourceMap: SourceMap{}})
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() compilationScope {
+	scope := c.currentScope()
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbolTable = c.symbolTable.Outer
+	return scope
+}
+
+// enterBlock scopes the names declared until leaveBlock to a for loop or match arm,
+// as Eval declares them in an enclosed environment.
+func (c *Compiler) enterBlock() {
+	c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveBlock() {
+	c.symbolTable = c.symbolTable.Outer
+}
@@ -0,0 +1,281 @@
+package Compiler
+
+import (
+	"Chimp/Lexer"
+	"Chimp/Parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpClosure, []int{65534, 255}, []byte{byte(OpClosure), 255, 254, 255}},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		if string(instruction) != string(tt.expected) {
+			t.Errorf("instruction encoded wrongly, expected: %v, got: %v", tt.expected, instruction)
+		}
+
+		def, err := Lookup(tt.op)
+		if err != nil {
+			t.Fatalf("definition not found: %s", err)
+		}
+		operands, read := ReadOperands(def, instruction[1:])
+		if read != len(instruction)-1 {
+			t.Errorf("wrong number of bytes read, expected: %d, got: %d", len(instruction)-1, read)
+		}
+		for i, operand := range operands {
+			if operand != tt.operands[i] {
+				t.Errorf("operand %d decoded wrongly, expected: %d, got: %d", i, tt.operands[i], operand)
+			}
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := Instructions{}
+	for _, ins := range [][]byte{Make(OpAdd), Make(OpGetLocal, 1), Make(OpConstant, 2), Make(OpClosure, 65535, 255)} {
+		instructions = append(instructions, ins...)
+	}
+
+	expected := "0000 OpAdd\n0001 OpGetLocal 1\n0003 OpConstant 2\n0006 OpClosure 65535 255\n"
+
+	if instructions.String() != expected {
+		t.Errorf("disassembly didn't match, expected:\n%s\ngot:\n%s", expected, instructions.String())
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input     string
+		constants []string
+		expected  [][]byte
+	}{
+		{"1 + 2", []string{"1", "2"}, [][]byte{
+			Make(OpConstant, 0),
+			Make(OpConstant, 1),
+			Make(OpAdd),
+		}},
+		{"1; true", []string{"1"}, [][]byte{
+			Make(OpConstant, 0),
+			Make(OpPop),
+			Make(OpTrue),
+		}},
+		{"", []string{}, [][]byte{
+			Make(OpNull),
+		}},
+		{"monkeySay x = 1; x", []string{"1"}, [][]byte{
+			Make(OpConstant, 0),
+			Make(OpSetGlobal, 0),
+			Make(OpGetGlobal, 0),
+			Make(OpPop),
+			Make(OpGetGlobal, 0),
+		}},
+		{"if (true) { 10 }", []string{"10"}, [][]byte{
+			Make(OpTrue),
+			Make(OpJumpNotTrue, 10),
+			Make(OpConstant, 0),
+			Make(OpJump, 11),
+			Make(OpNull),
+		}},
+		{`[1, "a"][0]`, []string{"1", "a", "0"}, [][]byte{
+			Make(OpConstant, 0),
+			Make(OpConstant, 1),
+			Make(OpArray, 2),
+			Make(OpConstant, 2),
+			Make(OpIndex),
+		}},
+		{"len([])", []string{"builtin len"}, [][]byte{
+			Make(OpConstant, 0),
+			Make(OpArray, 0),
+			Make(OpCall, 1),
+		}},
+		{"monkeyDo(x) { x }", []string{"(x) { x }"}, [][]byte{
+			Make(OpClosure, 0, 0),
+		}},
+	}
+
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+
+		expected := Instructions{}
+		for _, ins := range tt.expected {
+			expected = append(expected, ins...)
+		}
+		if bytecode.Instructions.String() != expected.String() {
+			t.Errorf("instructions of %q didn't match, expected:\n%s\ngot:\n%s", tt.input, expected, bytecode.Instructions)
+		}
+
+		if len(bytecode.Constants) != len(tt.constants) {
+			t.Fatalf("wrong number of constants for %q, expected: %d, got: %d", tt.input, len(tt.constants), len(bytecode.Constants))
+		}
+		for i, constant := range bytecode.Constants {
+			if constant.Inspect() != tt.constants[i] {
+				t.Errorf("constant %d of %q didn't match, expected: %s, got: %s", i, tt.input, tt.constants[i], constant.Inspect())
+			}
+		}
+	}
+}
+
+func TestCompileClosures(t *testing.T) {
+	input := "monkeySay f = monkeyDo(a) { monkeyDo(b) { f(a + b) } }"
+
+	bytecode := compile(t, input)
+
+	// each function is compiled once, the inner one before the outer one
+	if len(bytecode.Constants) != 2 {
+		t.Fatalf("expected 2 constants, got %d", len(bytecode.Constants))
+	}
+	outer, ok := bytecode.Constants[len(bytecode.Constants)-1].(*CompiledFunction)
+	if !ok {
+		t.Fatalf("constant is not a compiled function, is %T", bytecode.Constants[len(bytecode.Constants)-1])
+	}
+	inner := bytecode.Constants[len(bytecode.Constants)-2].(*CompiledFunction)
+
+	tests := []struct {
+		function *CompiledFunction
+		expected [][]byte
+	}{
+		{inner, [][]byte{
+			Make(OpGetFree, 0),
+			Make(OpGetFreeCell, 1),
+			Make(OpGetLocal, 0),
+			Make(OpAdd),
+			Make(OpCall, 1),
+			Make(OpReturnValue),
+		}},
+		{outer, [][]byte{
+			Make(OpGetLocal, 0),
+			Make(OpSetCell, 0),
+			Make(OpCurrentClosure),
+			Make(OpCell, 0),
+			Make(OpClosure, len(bytecode.Constants)-2, 2),
+			Make(OpReturnValue),
+		}},
+	}
+
+	for _, tt := range tests {
+		expected := Instructions{}
+		for _, ins := range tt.expected {
+			expected = append(expected, ins...)
+		}
+		if tt.function.Instructions.String() != expected.String() {
+			t.Errorf("function didn't match, expected:\n%s\ngot:\n%s", expected, tt.function.Instructions)
+		}
+	}
+
+	if outer.NumParameters != 1 || outer.NumLocals != 1 {
+		t.Errorf("outer function has %d parameters and %d locals, expected 1 and 1", outer.NumParameters, outer.NumLocals)
+	}
+}
+
+func TestCompileLimits(t *testing.T) {
+	// repeat joins n copies of format, each formatted with a distinct name made of
+	// letters, as identifiers cannot contain digits.
+	repeat := func(format string, n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			name := ""
+			for j := i; j > 0 || name == ""; j /= 26 {
+				name = string(rune('a'+j%26)) + name
+			}
+			parts[i] = fmt.Sprintf(format, name)
+		}
+		return strings.Join(parts, "")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"monkeyDo() { " + repeat("monkeySay x%s = true; ", 257) + "}",
+			"too many local bindings in a function: 256 exceeds the limit of 255"},
+		{"monkeyDo() { " + repeat("monkeySay a%s = true; ", 200) + "monkeyDo() { " + repeat("monkeySay b%s = true; ", 57) +
+			"monkeyDo() { [" + repeat("a%s, ", 200) + repeat("b%s, ", 57) + "true] } } }",
+			"too many variables captured by a function: 256 exceeds the limit of 255"},
+		{"f(" + strings.Repeat("true, ", 255) + "true)", "too many call arguments: 256 exceeds the limit of 255"},
+		{repeat("monkeySay g%s = true; ", 65537), "too many global bindings: 65536 exceeds the limit of 65535"},
+		{strings.Repeat("1; ", 65537), "too many constants: 65536 exceeds the limit of 65535"},
+		{"[" + strings.Repeat("true, ", 65535) + "true]", "too many array elements: 65536 exceeds the limit of 65535"},
+		{"{" + strings.Repeat("true: true, ", 32767) + "true: true}", "too many map keys and values: 65536 exceeds the limit of 65535"},
+		{"if (true) { " + strings.Repeat("true; ", 33000) + "}", "too many bytes of code in a function or programme: 66006 exceeds the limit of 65535"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := Parser.New(*l)
+		programme := p.ParseProgramme()
+		if len(p.GetErrors()) > 0 {
+			t.Fatalf("parse errors for %.40q: %v", tt.input, p.GetErrors()[0])
+		}
+
+		err := New().Compile(programme)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%.40q: expected error '%s', got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a")
+	local := NewEnclosedSymbolTable(global)
+	b := local.Define("b")
+	nested := NewEnclosedSymbolTable(local)
+	c := nested.Define("c")
+
+	tests := []struct {
+		table    *SymbolTable
+		name     string
+		expected Symbol
+	}{
+		{global, "a", a},
+		{local, "b", b},
+		{nested, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{nested, "b", Symbol{Name: "b", Scope: FreeScope, Index: 0}},
+		{nested, "c", c},
+	}
+
+	for _, tt := range tests {
+		symbol, ok := tt.table.Resolve(tt.name)
+		if !ok {
+			t.Fatalf("name %s not resolvable", tt.name)
+		}
+		if symbol != tt.expected {
+			t.Errorf("%s resolved wrongly, expected: %+v, got: %+v", tt.name, tt.expected, symbol)
+		}
+	}
+
+	if len(nested.FreeSymbols) != 1 || nested.FreeSymbols[0] != b {
+		t.Errorf("expected b to be captured as free, got %+v", nested.FreeSymbols)
+	}
+	if again := global.Define("a"); again != a {
+		t.Errorf("redefining a should reuse its slot, got %+v", again)
+	}
+	if _, ok := nested.Resolve("missing"); ok {
+		t.Errorf("missing should not resolve")
+	}
+}
+
+func compile(t *testing.T, input string) *Bytecode {
+	l := Lexer.New(input)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+
+	compiler := New()
+	if err := compiler.Compile(programme); err != nil {
+		t.Fatalf("unexpected compile error for %q: %s", input, err)
+	}
+	return compiler.Bytecode()
+}
@@ -0,0 +1,133 @@
+package Compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	// Boxed locals live in a cell shared with the closures that capture them, so that
+	// assignments on either side are seen by the other.
+	Boxed bool
+}
+
+// SymbolTable resolves names to global slots, local slots of the enclosing function,
+// or free variables captured by a closure.
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol
+
+	store          map[string]Symbol
+	numDefinitions int
+
+	// boxed names the locals to box when defined.
+	boxed map[string]bool
+	// block tables hold the names declared by a for loop or a match arm, which take
+	// slots of the table around them but go out of scope with the block.
+	block bool
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: map[string]Symbol{}, boxed: map[string]bool{}}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// NewBlockSymbolTable returns a table for the names a block declares, which are
+// given slots of the function, or of the globals, that the block is in.
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewEnclosedSymbolTable(outer)
+	s.block = true
+	return s
+}
+
+// Define binds name in this table, reusing the slot of an earlier definition.
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) {
+		return symbol
+	}
+
+	symbol := s.slots().newSlot(name)
+	s.store[name] = symbol
+	return symbol
+}
+
+// slots returns the table whose slots the names defined in this one take.
+func (s *SymbolTable) slots() *SymbolTable {
+	if s.block {
+		return s.Outer.slots()
+	}
+	return s
+}
+
+func (s *SymbolTable) newSlot(name string) Symbol {
+	symbol := Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+	} else {
+		symbol.Scope = LocalScope
+		symbol.Boxed = s.boxed[name]
+	}
+
+	s.numDefinitions++
+	return symbol
+}
+
+// DefineFunctionName binds the name a function is being defined under to the
+// function itself, so that it can call itself before the binding is made.
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Scope: FunctionScope, Index: 0}
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if ok || s.Outer == nil {
+		return symbol, ok
+	}
+
+	symbol, ok = s.Outer.Resolve(name)
+	if !ok || symbol.Scope == GlobalScope || s.block {
+		return symbol, ok
+	}
+	return s.defineFree(symbol), true
+}
+
+// Global returns the outermost table, which holds the globals.
+func (s *SymbolTable) Global() *SymbolTable {
+	if s.Outer == nil {
+		return s
+	}
+	return s.Outer.Global()
+}
+
+// Names lists the defined names by slot index, leaving those of blocks empty.
+func (s *SymbolTable) Names() []string {
+	names := make([]string, s.numDefinitions)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope || symbol.Scope == LocalScope {
+			names[symbol.Index] = name
+		}
+	}
+	return names
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1, Boxed: original.Boxed}
+	s.store[original.Name] = symbol
+	return symbol
+}
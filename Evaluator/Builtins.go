@@ -0,0 +1,181 @@
+package Evaluator
+
+import (
+	"Chimp/Object"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// builtins are consulted when an identifier is not bound in the environment and is
+// not one of its own builtins. They are shared by every environment in the process,
+// interpreters and compilers alike, so are best registered before any programme runs.
+var (
+	builtinsMutex sync.RWMutex
+	builtins      = map[string]Object.Builtin{}
+)
+
+func init() {
+	RegisterBuiltin("len", builtinLen)
+	RegisterBuiltin("first", builtinFirst)
+	RegisterBuiltin("last", builtinLast)
+	RegisterBuiltin("rest", builtinRest)
+	RegisterBuiltin("push", builtinPush)
+	RegisterBuiltin("puts", builtinPuts)
+	RegisterBuiltin("put", builtinPuts)
+	RegisterBuiltin("print", builtinPrint)
+	RegisterBuiltin("type", builtinType)
+	RegisterBuiltin("args", builtinArgs)
+}
+
+// RegisterBuiltin makes a Go function callable from Chimp under the given name, in
+// every environment of the process. Bindings in the environment, and builtins set on
+// it with SetBuiltin, take precedence over builtins of the same name.
+func RegisterBuiltin(name string, fn Object.BuiltinFunction) {
+	builtinsMutex.Lock()
+	defer builtinsMutex.Unlock()
+	builtins[name] = Object.Builtin{Name: name, Fn: fn}
+}
+
+// LookupBuiltin returns the builtin registered under name.
+func LookupBuiltin(name string) (Object.Builtin, bool) {
+	builtinsMutex.RLock()
+	defer builtinsMutex.RUnlock()
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
+
+// BuiltinNames returns the names of every registered builtin, sorted.
+func BuiltinNames() []string {
+	builtinsMutex.RLock()
+	defer builtinsMutex.RUnlock()
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func builtinLen(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	if err := checkArgumentCount(args, 1); err != nil {
+		return nil, err
+	}
+
+	switch arg := args[0].(type) {
+	case Object.Array:
+		return Object.Integer{Value: int64(len(arg.Elements))}, nil
+	case Object.String:
+		return Object.Integer{Value: int64(len(arg.Value))}, nil
+	case Object.Map:
+		return Object.Integer{Value: int64(len(arg.Pairs))}, nil
+	}
+	return nil, fmt.Errorf("argument of type %s is not supported", args[0].Type())
+}
+
+func builtinFirst(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	array, err := arrayArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(array.Elements) == 0 {
+		return nil, fmt.Errorf("array is empty")
+	}
+	return array.Elements[0], nil
+}
+
+func builtinLast(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	array, err := arrayArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(array.Elements) == 0 {
+		return nil, fmt.Errorf("array is empty")
+	}
+	return array.Elements[len(array.Elements)-1], nil
+}
+
+func builtinRest(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	array, err := arrayArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(array.Elements) == 0 {
+		return Object.Array{Elements: []Object.Object{}}, nil
+	}
+	elements := make([]Object.Object, len(array.Elements)-1)
+	copy(elements, array.Elements[1:])
+	return Object.Array{Elements: elements}, nil
+}
+
+// builtinPush returns a new array, leaving the original untouched.
+func builtinPush(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	array, err := arrayArgument(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]Object.Object, len(array.Elements), len(array.Elements)+1)
+	copy(elements, array.Elements)
+	return Object.Array{Elements: append(elements, args[1])}, nil
+}
+
+// builtinPuts writes each argument on its own line.
+func builtinPuts(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	for _, arg := range args {
+		if _, err := fmt.Fprintln(env.Output(), arg.Inspect()); err != nil {
+			return nil, err
+		}
+	}
+	return Object.Null{}, nil
+}
+
+// builtinPrint writes its arguments separated by spaces, without a trailing newline.
+func builtinPrint(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = arg.Inspect()
+	}
+	if _, err := fmt.Fprint(env.Output(), strings.Join(values, " ")); err != nil {
+		return nil, err
+	}
+	return Object.Null{}, nil
+}
+
+func builtinType(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	if err := checkArgumentCount(args, 1); err != nil {
+		return nil, err
+	}
+	return Object.String{Value: string(args[0].Type())}, nil
+}
+
+// builtinArgs returns the command-line arguments given to the script as strings.
+func builtinArgs(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	if err := checkArgumentCount(args, 0); err != nil {
+		return nil, err
+	}
+	elements := []Object.Object{}
+	for _, arg := range env.Args() {
+		elements = append(elements, Object.String{Value: arg})
+	}
+	return Object.Array{Elements: elements}, nil
+}
+
+func checkArgumentCount(args []Object.Object, expected int) error {
+	if len(args) != expected {
+		return fmt.Errorf("expected %d arguments, got %d", expected, len(args))
+	}
+	return nil
+}
+
+// arrayArgument checks the argument count and that the first argument is an array.
+func arrayArgument(args []Object.Object, expected int) (Object.Array, error) {
+	if err := checkArgumentCount(args, expected); err != nil {
+		return Object.Array{}, err
+	}
+	array, ok := args[0].(Object.Array)
+	if !ok {
+		return Object.Array{}, fmt.Errorf("first argument must be ARRAY, got %s", args[0].Type())
+	}
+	return array, nil
+}
@@ -3,57 +3,277 @@ package Evaluator
 import (
 	"Chimp/Ast"
 	"Chimp/Object"
+	"Chimp/Token"
+	"context"
 	"errors"
+	"math"
+	"math/big"
+	"strings"
 )
 
-func Eval(node Ast.Node, env *Object.Environment) (obj Object.Object, err error) {
-	if err != nil {
+// Eval evaluates node in env within DefaultLimits.
+func Eval(node Ast.Node, env *Object.Environment) (Object.Object, error) {
+	return EvalContext(context.Background(), node, env, DefaultLimits)
+}
+
+// EvalContext evaluates node in env, stopping with a LimitError when ctx is done or
+// the limits are exceeded.
+func EvalContext(ctx context.Context, node Ast.Node, env *Object.Environment, limits Limits) (Object.Object, error) {
+	e := newEvaluator(ctx, limits)
+	return e.eval(node, env)
+}
+
+// evaluator holds the state of a single evaluation used to enforce its limits.
+type evaluator struct {
+	ctx    context.Context
+	limits Limits
+	steps  int
+	depth  int
+}
+
+func newEvaluator(ctx context.Context, limits Limits) *evaluator {
+	return &evaluator{ctx: ctx, limits: limits}
+}
+
+func (e *evaluator) eval(node Ast.Node, env *Object.Environment) (obj Object.Object, err error) {
+	if err := e.step(); err != nil {
 		return nil, err
 	}
+
 	switch node := node.(type) {
 	case Ast.Programme:
-		return evalStatements(node.Statements, env)
+		return e.evalProgramme(node.Statements, env)
 	case Ast.ExpressionStatement:
-		return Eval(node.Value, env)
+		return e.eval(node.Value, env)
 	case *Ast.LetStatement:
-		object, _ := Eval(node.Value, env)
+		object, err := e.eval(node.Value, env)
+		if err != nil || isSignal(object) {
+			return object, err
+		}
 		env.Set(node.Name.Value, object)
-		return Eval(node.Value, env)
+		return object, nil
 	case *Ast.IdentityExpression:
-		val, ok := env.Get(node.Value)
-		if ok {
-			return val, nil
-		} else {
-			return nil, errors.New(wrongIdentifierErrorMsg(node.Value))
+		val, ok := lookupIdentifier(node.Value, env)
+		if !ok {
+			return nil, errors.New(wrongIdentifierErrorMsg(node.Token.Position, node.Value))
 		}
+		return val, nil
 	case *Ast.InfixExpression:
-		return evalInfix(node, env)
+		return e.evalInfix(node, env)
 	case Ast.BlockStatement:
-		return evalStatements(node.Statements, env)
+		return e.evalStatements(node.Statements, env)
 	case *Ast.ReturnStatement:
-		return Eval(node.Value, env)
+		value, err := e.eval(node.Value, env)
+		if err != nil || isSignal(value) {
+			return value, err
+		}
+		return Object.ReturnValue{Value: value}, nil
 	case Ast.IfStatement:
-		object, _ := Eval(node.Condition, env)
-		boolExpression := object.(Object.Boolean)
-
-		if boolExpression.Value {
-			return Eval(node.Then, env)
+		object, err := e.eval(node.Condition, env)
+		if err != nil || isSignal(object) {
+			return object, err
+		}
+		if IsTruthy(object) {
+			return e.eval(node.Then, env)
 		} else {
-			return Eval(node.Else, env)
+			return e.eval(node.Else, env)
 		}
+	case *Ast.MatchExpression:
+		return e.evalMatch(node, env)
+	case *Ast.AssignExpression:
+		return e.evalAssign(node, env)
+	case *Ast.UpdateExpression:
+		return e.evalUpdate(node, env)
+	case *Ast.WhileStatement:
+		return e.evalLoop(nil, node.Condition, nil, node.Body, env)
+	case *Ast.ForStatement:
+		return e.evalLoop(node.Init, node.Condition, node.Post, node.Body, Object.NewEnvironment(env))
+	case *Ast.BreakStatement:
+		return Object.Break{}, nil
+	case *Ast.ContinueStatement:
+		return Object.Continue{}, nil
 	case *Ast.PrefixExpression:
-		return evalPrefix(node, env), nil
+		return e.evalPrefix(node, env)
 	case *Ast.IntegerExpression:
+		if node.Big != nil {
+			return Object.BigInteger{Value: node.Big}, nil
+		}
 		return Object.Integer{Value: node.Value}, nil
+	case *Ast.FloatExpression:
+		return Object.Float{Value: node.Value}, nil
 	case *Ast.BoolExpression:
 		return Object.Boolean{Value: node.Value}, nil
+	case *Ast.StringExpression:
+		return Object.String{Value: node.Value}, nil
+	case *Ast.ArrayExpression:
+		elements, signal, err := e.evalExpressions(node.Elements, env)
+		if err != nil || signal != nil {
+			return signal, err
+		}
+		return Object.Array{Elements: elements}, nil
+	case *Ast.MapExpression:
+		return e.evalMap(node, env)
+	case *Ast.IndexExpression:
+		return e.evalIndex(node, env)
 	case *Ast.FunctionExpression:
 		return evalFunction(node, env), nil
 	case *Ast.CallExpression:
-		return evalCall(node, env)
+		return e.evalCall(node, env)
+	}
+
+	return nil, errors.New(unsupportedNodeErrorMsg(node))
+}
+
+// IsTruthy decides which branch an if takes, whether a loop goes round again and
+// what "!", "&&" and "||" make of a value. False, null, zeros and empty strings,
+// arrays and maps are falsy, every other value is truthy.
+func IsTruthy(object Object.Object) bool {
+	switch object := object.(type) {
+	case Object.Boolean:
+		return object.Value
+	case Object.Null:
+		return false
+	case Object.Integer:
+		return object.Value != 0
+	case Object.Float:
+		return object.Value != 0
+	case Object.String:
+		return object.Value != ""
+	case Object.Array:
+		return len(object.Elements) > 0
+	case Object.Map:
+		return len(object.Pairs) > 0
+	}
+	return true
+}
+
+// evalMatch evaluates the body of the first arm whose pattern matches the value. The
+// body is evaluated in an enclosed env, where a binding pattern declares its name.
+func (e *evaluator) evalMatch(node *Ast.MatchExpression, env *Object.Environment) (Object.Object, error) {
+	value, err := e.eval(node.Value, env)
+	if err != nil || isSignal(value) {
+		return value, err
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := Object.NewEnvironment(env)
+		if identifier, ok := arm.Pattern.(*Ast.IdentityExpression); ok {
+			if identifier.Value != "_" {
+				armEnv.Set(identifier.Value, value)
+			}
+			return e.eval(arm.Body, armEnv)
+		}
+
+		pattern, err := e.eval(arm.Pattern, env)
+		if err != nil {
+			return nil, err
+		}
+		if objectsEqual(value, pattern) {
+			return e.eval(arm.Body, armEnv)
+		}
+	}
+
+	return nil, errors.New(noMatchingArmErrorMsg(node.Token.Position, value.Inspect()))
+}
+
+// evalAssign rebinds the name in the scope that declared it, after checking that it
+// is declared so that an undeclared name fails before the value is evaluated.
+func (e *evaluator) evalAssign(node *Ast.AssignExpression, env *Object.Environment) (Object.Object, error) {
+	current, ok := env.Get(node.Name.Value)
+	if !ok {
+		return nil, errors.New(undeclaredAssignmentErrorMsg(node.Name.Token.Position, node.Name.Value))
+	}
+
+	value, err := e.eval(node.Value, env)
+	if err != nil || isSignal(value) {
+		return value, err
+	}
+
+	if node.Operator != "=" {
+		operator := strings.TrimSuffix(node.Operator, "=")
+		value, err = EvalInfixOperation(node.Token.Position, operator, current, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env.Assign(node.Name.Value, value)
+	return value, nil
+}
+
+func (e *evaluator) evalUpdate(node *Ast.UpdateExpression, env *Object.Environment) (Object.Object, error) {
+	current, ok := env.Get(node.Name.Value)
+	if !ok {
+		return nil, errors.New(undeclaredAssignmentErrorMsg(node.Name.Token.Position, node.Name.Value))
+	}
+
+	value, err := EvalInfixOperation(node.Token.Position, node.Operator[:1], current, Object.Integer{Value: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	env.Assign(node.Name.Value, value)
+	if node.Prefix {
+		return value, nil
+	}
+	return current, nil
+}
+
+// evalLoop runs body while condition holds, a nil condition never ending the loop by
+// itself. A loop evaluates to null unless a return statement leaves it. For loops are
+// given an enclosed env, so that the names they declare go out of scope with them.
+func (e *evaluator) evalLoop(init Ast.Statement, condition Ast.Expression, post Ast.Statement, body Ast.BlockStatement, env *Object.Environment) (Object.Object, error) {
+	if init != nil {
+		if _, err := e.eval(init, env); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if condition != nil {
+			object, err := e.eval(condition, env)
+			if err != nil || isSignal(object) {
+				return object, err
+			}
+			if !IsTruthy(object) {
+				break
+			}
+		}
+
+		result, err := e.eval(body, env)
+		if err != nil {
+			return nil, err
+		}
+		switch result.(type) {
+		case Object.ReturnValue:
+			return result, nil
+		case Object.Break:
+			return Object.Null{}, nil
+		}
+
+		if post != nil {
+			if _, err := e.eval(post, env); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	return nil, nil
+	return Object.Null{}, nil
+}
+
+// lookupIdentifier resolves a name in env, falling back to the builtins of env and
+// then to those registered for every environment.
+func lookupIdentifier(name string, env *Object.Environment) (Object.Object, bool) {
+	if val, ok := env.Get(name); ok {
+		return val, true
+	}
+	if builtin, ok := env.Builtin(name); ok {
+		return builtin, true
+	}
+	if builtin, ok := LookupBuiltin(name); ok {
+		return builtin, true
+	}
+	return nil, false
 }
 
 func evalFunction(node *Ast.FunctionExpression, env *Object.Environment) Object.Object {
@@ -61,85 +281,360 @@ func evalFunction(node *Ast.FunctionExpression, env *Object.Environment) Object.
 	for _, p := range node.Parameters {
 		params = append(params, p.ToString())
 	}
-	return Object.Function{
+	return &Object.Function{
 		Parameters: params,
 		Body:       node.Body,
 		Env:        env,
 	}
 }
 
-func evalCall(node *Ast.CallExpression, env *Object.Environment) (obj Object.Object, err error) {
-	targetObject, _ := Eval(node.Target, env)
-	function, ok := targetObject.(Object.Function)
+func (e *evaluator) evalCall(node *Ast.CallExpression, env *Object.Environment) (obj Object.Object, err error) {
+	var targetObject Object.Object
+	if identifier, isIdentifier := node.Target.(*Ast.IdentityExpression); isIdentifier {
+		targetObject, _ = lookupIdentifier(identifier.Value, env)
+	} else {
+		targetObject, err = e.eval(node.Target, env)
+		if err != nil || isSignal(targetObject) {
+			return targetObject, err
+		}
+	}
+	if builtin, ok := targetObject.(Object.Builtin); ok {
+		return e.evalBuiltinCall(node, builtin, env)
+	}
+
+	function, ok := targetObject.(*Object.Function)
 	if !ok {
-		return nil, errors.New(unknownFunctionErrorMsg(node.Target.ToString()))
+		return nil, errors.New(unknownFunctionErrorMsg(node.Token.Position, node.Target.ToString()))
+	}
+
+	if len(node.Parameters) != len(function.Parameters) {
+		return nil, errors.New(wrongArgumentCountErrorMsg(node.Token.Position, node.Target.ToString(), len(function.Parameters), len(node.Parameters)))
+	}
+
+	args, signal, err := e.evalExpressions(node.Parameters, env)
+	if err != nil || signal != nil {
+		return signal, err
+	}
+	return e.applyFunction(node.Token.Position, function, args)
+}
+
+// ApplyFunction calls a function or builtin with arguments that are already evaluated,
+// for callers outside of a programme such as Go host code. Builtins run with env as
+// their call site environment.
+func ApplyFunction(fn Object.Object, env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	return ApplyFunctionContext(context.Background(), DefaultLimits, fn, env, args...)
+}
+
+// ApplyFunctionContext is ApplyFunction with the cancellation and limits of EvalContext.
+func ApplyFunctionContext(ctx context.Context, limits Limits, fn Object.Object, env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+	switch fn := fn.(type) {
+	case *Object.Function:
+		if len(args) != len(fn.Parameters) {
+			return nil, errors.New(argumentCountErrorMsg(len(fn.Parameters), len(args)))
+		}
+		return newEvaluator(ctx, limits).applyFunction(Token.Position{}, fn, args)
+	case Object.Builtin:
+		return fn.Fn(env, args...)
+	}
+	return nil, errors.New(notAFunctionErrorMsg(fn.Inspect()))
+}
+
+func (e *evaluator) applyFunction(pos Token.Position, function *Object.Function, args []Object.Object) (Object.Object, error) {
+	if err := e.enterCall(pos); err != nil {
+		return nil, err
 	}
+	defer e.exitCall()
 
 	extendedScope := Object.NewEnvironment(function.Env)
-	for i, paramValue := range node.Parameters {
-		paramObjectValue, err := Eval(paramValue, env)
+	for i, arg := range args {
+		extendedScope.Set(function.Parameters[i], arg)
+	}
+	result, err := e.eval(function.Body, extendedScope)
+	return unwrapReturnValue(result), err
+}
+
+func (e *evaluator) evalBuiltinCall(node *Ast.CallExpression, builtin Object.Builtin, env *Object.Environment) (Object.Object, error) {
+	args, signal, err := e.evalExpressions(node.Parameters, env)
+	if err != nil || signal != nil {
+		return signal, err
+	}
+
+	result, err := builtin.Fn(env, args...)
+	if err != nil {
+		return nil, errors.New(builtinErrorMsg(node.Token.Position, builtin.Name, err))
+	}
+	return result, nil
+}
+
+// evalExpressions evaluates expressions in order, stopping early with the signal of
+// any that returns, breaks or continues.
+func (e *evaluator) evalExpressions(expressions []Ast.Expression, env *Object.Environment) ([]Object.Object, Object.Object, error) {
+	objects := make([]Object.Object, 0, len(expressions))
+	for _, expression := range expressions {
+		object, err := e.eval(expression, env)
 		if err != nil {
-			panic("this shouldn't happen")
+			return nil, nil, err
+		}
+		if isSignal(object) {
+			return nil, object, nil
 		}
-		extendedScope.Set(function.Parameters[i], paramObjectValue)
+		objects = append(objects, object)
 	}
-	return Eval(function.Body, extendedScope)
+	return objects, nil, nil
 }
 
-func evalPrefix(p *Ast.PrefixExpression, env *Object.Environment) Object.Object {
-	exp, _ := Eval(p.Expression, env)
+func (e *evaluator) evalMap(node *Ast.MapExpression, env *Object.Environment) (Object.Object, error) {
+	pairs := make(map[Object.HashKey]Object.MapPair, len(node.Pairs))
+	for _, pair := range node.Pairs {
+		key, err := e.eval(pair.Key, env)
+		if err != nil || isSignal(key) {
+			return key, err
+		}
+		hashKey, err := HashKeyOf(node.Token.Position, key)
+		if err != nil {
+			return nil, err
+		}
 
-	switch {
-	case exp.Type() == Object.INTEGER_OBJ:
-		expInteger := exp.(Object.Integer)
-		return evalPrefixInteger(p.Operator, expInteger.Value)
-	case exp.Type() == Object.BOOL_OBJ:
-		expBool := exp.(Object.Boolean)
-		return evalPrefixBool(p.Operator, expBool.Value)
+		value, err := e.eval(pair.Value, env)
+		if err != nil || isSignal(value) {
+			return value, err
+		}
+		pairs[hashKey] = Object.MapPair{Key: key, Value: value}
 	}
+	return Object.Map{Pairs: pairs}, nil
+}
 
-	return nil
+func (e *evaluator) evalIndex(node *Ast.IndexExpression, env *Object.Environment) (Object.Object, error) {
+	target, err := e.eval(node.Target, env)
+	if err != nil || isSignal(target) {
+		return target, err
+	}
+	index, err := e.eval(node.Index, env)
+	if err != nil || isSignal(index) {
+		return index, err
+	}
+
+	return EvalIndexOperation(node.Token.Position, target, index)
 }
 
-func evalPrefixInteger(operator string, value int64) Object.Object {
-	switch operator {
-	case "-":
-		return Object.Integer{Value: -value}
+// HashKeyOf returns the map key for key, or an error when key is not hashable.
+func HashKeyOf(pos Token.Position, key Object.Object) (Object.HashKey, error) {
+	hashable, ok := key.(Object.Hashable)
+	if !ok {
+		return Object.HashKey{}, errors.New(unhashableKeyErrorMsg(pos, key.Inspect()))
 	}
-	return nil
+	return hashable.HashKey(), nil
 }
 
-func evalPrefixBool(operator string, value bool) Object.Object {
-	switch operator {
-	case "!":
-		return Object.Boolean{Value: !value}
+// EvalIndexOperation indexes an evaluated array or map. Like the other exported
+// operations it is shared with the VM so both agree on the semantics.
+func EvalIndexOperation(pos Token.Position, target Object.Object, index Object.Object) (Object.Object, error) {
+	if m, ok := target.(Object.Map); ok {
+		hashKey, err := HashKeyOf(pos, index)
+		if err != nil {
+			return nil, err
+		}
+		if pair, ok := m.Pairs[hashKey]; ok {
+			return pair.Value, nil
+		}
+		return Object.Null{}, nil
 	}
-	return nil
+
+	array, ok := target.(Object.Array)
+	if !ok {
+		return nil, errors.New(invalidIndexOperation(pos, target.Inspect(), index.Inspect()))
+	}
+	integer, ok := index.(Object.Integer)
+	if !ok {
+		return nil, errors.New(invalidIndexOperation(pos, target.Inspect(), index.Inspect()))
+	}
+	if integer.Value < 0 || integer.Value >= int64(len(array.Elements)) {
+		return nil, errors.New(indexOutOfRangeErrorMsg(pos, integer.Value, len(array.Elements)))
+	}
+	return array.Elements[integer.Value], nil
+}
+
+func unwrapReturnValue(obj Object.Object) Object.Object {
+	if returnValue, ok := obj.(Object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
+}
+
+func (e *evaluator) evalPrefix(p *Ast.PrefixExpression, env *Object.Environment) (Object.Object, error) {
+	exp, err := e.eval(p.Expression, env)
+	if err != nil || isSignal(exp) {
+		return exp, err
+	}
+
+	return EvalPrefixOperation(p.Token.Position, p.Operator, exp)
+}
+
+// EvalPrefixOperation applies a prefix operator to an evaluated operand.
+func EvalPrefixOperation(pos Token.Position, operator string, exp Object.Object) (Object.Object, error) {
+	if operator == "!" {
+		return Object.Boolean{Value: !IsTruthy(exp)}, nil
+	}
+
+	if operator == "-" && exp.Type() == Object.INTEGER_OBJ {
+		if integer, ok := exp.(Object.Integer); ok && integer.Value != math.MinInt64 {
+			return Object.Integer{Value: -integer.Value}, nil
+		}
+		return Object.NewInteger(new(big.Int).Neg(bigIntOf(exp))), nil
+	}
+	if float, ok := exp.(Object.Float); ok && operator == "-" {
+		return Object.Float{Value: -float.Value}, nil
+	}
+
+	return nil, errors.New(invalidPrefixOperation(pos, exp.Inspect(), operator))
+}
+
+func (e *evaluator) evalInfix(infix *Ast.InfixExpression, env *Object.Environment) (Object.Object, error) {
+	if infix.Operator == "&&" || infix.Operator == "||" {
+		return e.evalLogical(infix, env)
+	}
+
+	left, err := e.eval(infix.LeftExpression, env)
+	if err != nil || isSignal(left) {
+		return left, err
+	}
+	right, err := e.eval(infix.RightExpression, env)
+	if err != nil || isSignal(right) {
+		return right, err
+	}
+
+	return EvalInfixOperation(infix.Token.Position, infix.Operator, left, right)
 }
 
-func evalInfix(infix *Ast.InfixExpression, env *Object.Environment) (Object.Object, error) {
-	left, _ := Eval(infix.LeftExpression, env)
-	right, _ := Eval(infix.RightExpression, env)
+// evalLogical evaluates the right operand only when the left one does not decide the
+// result on its own, a falsy value for "&&" and a truthy one for "||". The result is
+// the operand that decided it, so `name || "default"` picks the first truthy value.
+func (e *evaluator) evalLogical(infix *Ast.InfixExpression, env *Object.Environment) (Object.Object, error) {
+	left, err := e.eval(infix.LeftExpression, env)
+	if err != nil || isSignal(left) {
+		return left, err
+	}
+	if IsTruthy(left) == (infix.Operator == "||") {
+		return left, nil
+	}
+
+	return e.eval(infix.RightExpression, env)
+}
+
+// EvalInfixOperation applies an infix operator to evaluated operands. Any two values
+// can be compared with "==" and "!=", while only numbers and strings are ordered.
+// An operation on an integer and a float converts the integer to a float.
+func EvalInfixOperation(pos Token.Position, operator string, left Object.Object, right Object.Object) (Object.Object, error) {
+	switch operator {
+	case "==":
+		return Object.Boolean{Value: objectsEqual(left, right)}, nil
+	case "!=":
+		return Object.Boolean{Value: !objectsEqual(left, right)}, nil
+	}
 
 	switch {
 	case left.Type() == Object.INTEGER_OBJ && right.Type() == Object.INTEGER_OBJ:
-		leftInteger := left.(Object.Integer)
-		rightInteger := right.(Object.Integer)
-		return evalInfixInteger(infix.Operator, leftInteger.Value, rightInteger.Value), nil
+		if divisor, ok := right.(Object.Integer); ok && operator == "/" && divisor.Value == 0 {
+			return nil, errors.New(divisionByZeroErrorMsg(pos))
+		}
+		leftInteger, leftSmall := left.(Object.Integer)
+		rightInteger, rightSmall := right.(Object.Integer)
+		if leftSmall && rightSmall {
+			if result := evalInfixInteger(operator, leftInteger.Value, rightInteger.Value); result != nil {
+				return result, nil
+			}
+		} else if result := evalInfixBigInteger(operator, bigIntOf(left), bigIntOf(right)); result != nil {
+			return result, nil
+		}
+	case isNumber(left) && isNumber(right):
+		leftFloat, rightFloat := floatOf(left), floatOf(right)
+		if operator == "/" && rightFloat == 0 {
+			return nil, errors.New(divisionByZeroErrorMsg(pos))
+		}
+		if result := evalInfixFloat(operator, leftFloat, rightFloat); result != nil {
+			return result, nil
+		}
+	case left.Type() == Object.STRING_OBJ && right.Type() == Object.STRING_OBJ:
+		leftString := left.(Object.String)
+		rightString := right.(Object.String)
+		if result := evalInfixString(operator, leftString.Value, rightString.Value); result != nil {
+			return result, nil
+		}
 	}
 
-	return nil, errors.New(invalidInfixOperation(left.Inspect(), right.Inspect(), infix.Operator))
+	switch operator {
+	case "<", "<=", ">", ">=":
+		return nil, errors.New(unorderedOperandsErrorMsg(pos, operator, left.Type(), right.Type()))
+	}
+	return nil, errors.New(invalidInfixOperation(pos, left.Inspect(), right.Inspect(), operator))
+}
+
+// objectsEqual compares values of different types as unequal, except for integers and
+// floats which are compared as floats. Arrays and maps are compared by their contents
+// and functions by identity.
+func objectsEqual(left Object.Object, right Object.Object) bool {
+	if left.Type() != right.Type() {
+		return isNumber(left) && isNumber(right) && floatOf(left) == floatOf(right)
+	}
+
+	switch left := left.(type) {
+	case Object.Array:
+		right := right.(Object.Array)
+		if len(left.Elements) != len(right.Elements) {
+			return false
+		}
+		for i := range left.Elements {
+			if !objectsEqual(left.Elements[i], right.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case Object.Map:
+		right := right.(Object.Map)
+		if len(left.Pairs) != len(right.Pairs) {
+			return false
+		}
+		for key, pair := range left.Pairs {
+			other, ok := right.Pairs[key]
+			if !ok || !objectsEqual(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	case Object.BigInteger:
+		right, ok := right.(Object.BigInteger)
+		return ok && left.Value.Cmp(right.Value) == 0
+	case Object.Builtin:
+		return left.Name == right.(Object.Builtin).Name
+	}
+
+	return left == right
 }
 
+// evalInfixInteger falls back to evalInfixBigInteger for results that overflow an
+// int64. The divisor is never zero.
 func evalInfixInteger(operator string, leftInteger, rightInteger int64) Object.Object {
 	switch operator {
 	case "+":
-		return Object.Integer{Value: leftInteger + rightInteger}
+		if sum := leftInteger + rightInteger; (sum > leftInteger) == (rightInteger > 0) {
+			return Object.Integer{Value: sum}
+		}
+		return evalInfixBigInteger(operator, big.NewInt(leftInteger), big.NewInt(rightInteger))
 	case "-":
-		return Object.Integer{Value: leftInteger - rightInteger}
+		if difference := leftInteger - rightInteger; (difference < leftInteger) == (rightInteger > 0) {
+			return Object.Integer{Value: difference}
+		}
+		return evalInfixBigInteger(operator, big.NewInt(leftInteger), big.NewInt(rightInteger))
 	case "*":
-		return Object.Integer{Value: leftInteger * rightInteger}
+		product := leftInteger * rightInteger
+		if leftInteger == 0 || (product/leftInteger == rightInteger && !(leftInteger == -1 && rightInteger == math.MinInt64)) {
+			return Object.Integer{Value: product}
+		}
+		return evalInfixBigInteger(operator, big.NewInt(leftInteger), big.NewInt(rightInteger))
 	case "/":
+		if leftInteger == math.MinInt64 && rightInteger == -1 {
+			return evalInfixBigInteger(operator, big.NewInt(leftInteger), big.NewInt(rightInteger))
+		}
 		return Object.Integer{Value: leftInteger / rightInteger}
 	case ">":
 		return Object.Boolean{Value: leftInteger > rightInteger}
@@ -149,23 +644,131 @@ func evalInfixInteger(operator string, leftInteger, rightInteger int64) Object.O
 		return Object.Boolean{Value: leftInteger < rightInteger}
 	case "<=":
 		return Object.Boolean{Value: leftInteger <= rightInteger}
-	case "==":
-		return Object.Boolean{Value: leftInteger == rightInteger}
-	case "!=":
-		return Object.Boolean{Value: leftInteger != rightInteger}
 	}
 	return nil
 }
 
-func evalStatements(statements []Ast.Statement, env *Object.Environment) (Object.Object, error) {
+// evalInfixBigInteger is evalInfixInteger for operands of any size, dividing with
+// truncation towards zero as int64 division does.
+func evalInfixBigInteger(operator string, leftInteger, rightInteger *big.Int) Object.Object {
+	switch operator {
+	case "+":
+		return Object.NewInteger(new(big.Int).Add(leftInteger, rightInteger))
+	case "-":
+		return Object.NewInteger(new(big.Int).Sub(leftInteger, rightInteger))
+	case "*":
+		return Object.NewInteger(new(big.Int).Mul(leftInteger, rightInteger))
+	case "/":
+		return Object.NewInteger(new(big.Int).Quo(leftInteger, rightInteger))
+	case ">":
+		return Object.Boolean{Value: leftInteger.Cmp(rightInteger) > 0}
+	case ">=":
+		return Object.Boolean{Value: leftInteger.Cmp(rightInteger) >= 0}
+	case "<":
+		return Object.Boolean{Value: leftInteger.Cmp(rightInteger) < 0}
+	case "<=":
+		return Object.Boolean{Value: leftInteger.Cmp(rightInteger) <= 0}
+	}
+	return nil
+}
+
+// bigIntOf returns the value of an Integer or BigInteger as a big.Int that the caller
+// must not modify.
+func bigIntOf(integer Object.Object) *big.Int {
+	if integer, ok := integer.(Object.BigInteger); ok {
+		return integer.Value
+	}
+	return big.NewInt(integer.(Object.Integer).Value)
+}
+
+func evalInfixFloat(operator string, leftFloat, rightFloat float64) Object.Object {
+	switch operator {
+	case "+":
+		return Object.Float{Value: leftFloat + rightFloat}
+	case "-":
+		return Object.Float{Value: leftFloat - rightFloat}
+	case "*":
+		return Object.Float{Value: leftFloat * rightFloat}
+	case "/":
+		return Object.Float{Value: leftFloat / rightFloat}
+	case ">":
+		return Object.Boolean{Value: leftFloat > rightFloat}
+	case ">=":
+		return Object.Boolean{Value: leftFloat >= rightFloat}
+	case "<":
+		return Object.Boolean{Value: leftFloat < rightFloat}
+	case "<=":
+		return Object.Boolean{Value: leftFloat <= rightFloat}
+	}
+	return nil
+}
+
+func isNumber(object Object.Object) bool {
+	return object.Type() == Object.INTEGER_OBJ || object.Type() == Object.FLOAT_OBJ
+}
+
+// floatOf returns the value of a number as a float, rounding integers beyond the
+// precision of float64 to the nearest float.
+func floatOf(number Object.Object) float64 {
+	switch number := number.(type) {
+	case Object.Float:
+		return number.Value
+	case Object.Integer:
+		return float64(number.Value)
+	}
+	float, _ := new(big.Float).SetInt(bigIntOf(number)).Float64()
+	return float
+}
+
+func evalInfixString(operator string, leftString, rightString string) Object.Object {
+	switch operator {
+	case "+":
+		return Object.String{Value: leftString + rightString}
+	case ">":
+		return Object.Boolean{Value: leftString > rightString}
+	case ">=":
+		return Object.Boolean{Value: leftString >= rightString}
+	case "<":
+		return Object.Boolean{Value: leftString < rightString}
+	case "<=":
+		return Object.Boolean{Value: leftString <= rightString}
+	}
+	return nil
+}
+
+func (e *evaluator) evalProgramme(statements []Ast.Statement, env *Object.Environment) (Object.Object, error) {
+	result, err := e.evalStatements(statements, env)
+	return unwrapReturnValue(result), err
+}
+
+// isSignal reports whether obj is a return, break or continue on its way out of the
+// statements that produced it, which also ends any expression it was evaluated in.
+func isSignal(obj Object.Object) bool {
+	switch obj.(type) {
+	case Object.ReturnValue, Object.Break, Object.Continue:
+		return true
+	}
+	return false
+}
+
+// evalStatements stops at the first return statement, handing its wrapped value back
+// so that enclosing blocks stop too until it reaches a function call or the programme.
+func (e *evaluator) evalStatements(statements []Ast.Statement, env *Object.Environment) (Object.Object, error) {
 	var (
-		eval Object.Object
+		eval Object.Object = Object.Null{}
 		err  error
 	)
 
 	for _, statement := range statements {
-		eval, err = Eval(statement, env)
+		eval, err = e.eval(statement, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if isSignal(eval) {
+			return eval, nil
+		}
 	}
 
-	return eval, err
+	return eval, nil
 }
@@ -1,17 +1,98 @@
 package Evaluator
 
 import (
+	"Chimp/Object"
+	"Chimp/Token"
+	"errors"
 	"fmt"
 )
 
-func wrongIdentifierErrorMsg(identifier string) string {
-	return fmt.Sprintf("Cannot find indentifier '%s'.", identifier)
+func wrongIdentifierErrorMsg(pos Token.Position, identifier string) string {
+	return fmt.Sprintf("%s: Cannot find indentifier '%s'.", pos, identifier)
 }
 
-func unknownFunctionErrorMsg(funcName string) string {
-	return fmt.Sprintf("Could not find function '%s'", funcName)
+func unknownFunctionErrorMsg(pos Token.Position, funcName string) string {
+	return fmt.Sprintf("%s: Could not find function '%s'", pos, funcName)
 }
 
-func invalidInfixOperation(left string, right string, op string) string {
-	return fmt.Sprintf("Invalid infix operation: Cannot use '%s' with '%s' and '%s'", op, left, right)
-}
\ No newline at end of file
+func invalidInfixOperation(pos Token.Position, left string, right string, op string) string {
+	return fmt.Sprintf("%s: Invalid infix operation: Cannot use '%s' with '%s' and '%s'", pos, op, left, right)
+}
+
+func unorderedOperandsErrorMsg(pos Token.Position, operator string, left Object.ObjectType, right Object.ObjectType) string {
+	return fmt.Sprintf("%s: Type error: cannot order %s and %s with '%s'", pos, left, right, operator)
+}
+
+func divisionByZeroErrorMsg(pos Token.Position) string {
+	return fmt.Sprintf("%s: Division by zero", pos)
+}
+
+func invalidPrefixOperation(pos Token.Position, value string, op string) string {
+	return fmt.Sprintf("%s: Invalid prefix operation: Cannot use '%s' with '%s'", pos, op, value)
+}
+
+func wrongArgumentCountErrorMsg(pos Token.Position, funcName string, expected int, got int) string {
+	return fmt.Sprintf("%s: Function '%s' expects %d arguments, got %d", pos, funcName, expected, got)
+}
+
+func unsupportedNodeErrorMsg(node interface{}) string {
+	return fmt.Sprintf("Cannot evaluate node of type %T", node)
+}
+
+func invalidIndexOperation(pos Token.Position, target string, index string) string {
+	return fmt.Sprintf("%s: Invalid index operation: Cannot index '%s' with '%s'", pos, target, index)
+}
+
+func indexOutOfRangeErrorMsg(pos Token.Position, index int64, length int) string {
+	return fmt.Sprintf("%s: Index %d out of range for array of length %d", pos, index, length)
+}
+
+func builtinErrorMsg(pos Token.Position, name string, err error) string {
+	return fmt.Sprintf("%s: %s: %s", pos, name, err.Error())
+}
+
+func unhashableKeyErrorMsg(pos Token.Position, key string) string {
+	return fmt.Sprintf("%s: Cannot use '%s' as a map key", pos, key)
+}
+
+func undeclaredAssignmentErrorMsg(pos Token.Position, identifier string) string {
+	return fmt.Sprintf("%s: Cannot assign to undeclared identifier '%s'", pos, identifier)
+}
+
+func noMatchingArmErrorMsg(pos Token.Position, value string) string {
+	return fmt.Sprintf("%s: No match arm matches '%s'", pos, value)
+}
+
+func argumentCountErrorMsg(expected int, got int) string {
+	return fmt.Sprintf("Function expects %d arguments, got %d", expected, got)
+}
+
+func notAFunctionErrorMsg(value string) string {
+	return fmt.Sprintf("'%s' is not a function", value)
+}
+
+// The errors below are shared with the VM, which reports failures the way Eval does.
+
+func UnknownIdentifierError(pos Token.Position, identifier string) error {
+	return errors.New(wrongIdentifierErrorMsg(pos, identifier))
+}
+
+func UndeclaredAssignmentError(pos Token.Position, identifier string) error {
+	return errors.New(undeclaredAssignmentErrorMsg(pos, identifier))
+}
+
+func UnknownFunctionError(pos Token.Position, funcName string) error {
+	return errors.New(unknownFunctionErrorMsg(pos, funcName))
+}
+
+func WrongArgumentCountError(pos Token.Position, funcName string, expected int, got int) error {
+	return errors.New(wrongArgumentCountErrorMsg(pos, funcName, expected, got))
+}
+
+func NoMatchingArmError(pos Token.Position, value string) error {
+	return errors.New(noMatchingArmErrorMsg(pos, value))
+}
+
+func BuiltinError(pos Token.Position, name string, err error) error {
+	return errors.New(builtinErrorMsg(pos, name, err))
+}
@@ -4,8 +4,13 @@ import (
 	"Chimp/Lexer"
 	"Chimp/Object"
 	"Chimp/Parser"
+	"Chimp/Token"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestObjectInspect(t *testing.T) {
@@ -23,7 +28,7 @@ func TestObjectInspect(t *testing.T) {
 			inspection := evaluateTest(tt.input).Inspect()
 
 			if inspection != tt.expected {
-				t.Fatalf(fmt.Sprintf("inspect didn't match, expected: %s, got: %s", tt.expected, inspection))
+				t.Fatalf("inspect didn't match, expected: %s, got: %s", tt.expected, inspection)
 			}
 		}
 	}
@@ -34,12 +39,63 @@ func TestEvalErrors(t *testing.T) {
 		input    string
 		errorMsg string
 	}{
-		{"varThatDoesntExist", wrongIdentifierErrorMsg("varThatDoesntExist")},
-		{"badFunc(10)", unknownFunctionErrorMsg("badFunc")},
-		{"1 + true", invalidInfixOperation("1", "true", "+")},
-		{"1 > true", invalidInfixOperation("1", "true", ">")},
-		{"true + false", invalidInfixOperation("true", "false", "+")},
-		{"true < false", invalidInfixOperation("true", "false", "<")},
+		{"varThatDoesntExist", wrongIdentifierErrorMsg(at(1, 1), "varThatDoesntExist")},
+		{"match (4) { n => n }; n", wrongIdentifierErrorMsg(at(1, 23), "n")},
+		{"for (monkeySay i = 0; i < 3; i++) { monkeySay final = i }; final", wrongIdentifierErrorMsg(at(1, 60), "final")},
+		{"badFunc(10)", unknownFunctionErrorMsg(at(1, 8), "badFunc")},
+		{"1 + true", invalidInfixOperation(at(1, 3), "1", "true", "+")},
+		{"1 > true", unorderedOperandsErrorMsg(at(1, 3), ">", Object.INTEGER_OBJ, Object.BOOL_OBJ)},
+		{"true + false", invalidInfixOperation(at(1, 6), "true", "false", "+")},
+		{"true < false", unorderedOperandsErrorMsg(at(1, 6), "<", Object.BOOL_OBJ, Object.BOOL_OBJ)},
+		{"[1] >= [1]", unorderedOperandsErrorMsg(at(1, 5), ">=", Object.ARRAY_OBJ, Object.ARRAY_OBJ)},
+		{`1 <= "1"`, unorderedOperandsErrorMsg(at(1, 3), "<=", Object.INTEGER_OBJ, Object.STRING_OBJ)},
+		{"monkeyDo() { 1 } > len", unorderedOperandsErrorMsg(at(1, 18), ">", Object.FUNCTION_OBJ, Object.BUILTIN_OBJ)},
+		{`"a" - "b"`, invalidInfixOperation(at(1, 5), "a", "b", "-")},
+		{`"a" + 1`, invalidInfixOperation(at(1, 5), "a", "1", "+")},
+		{"monkeySay a = 1;\n  missing", wrongIdentifierErrorMsg(at(2, 3), "missing")},
+		{"1 + missing", wrongIdentifierErrorMsg(at(1, 5), "missing")},
+		{"monkeySay x = missing; x", wrongIdentifierErrorMsg(at(1, 15), "missing")},
+		{"missing; 1", wrongIdentifierErrorMsg(at(1, 1), "missing")},
+		{"-true", invalidPrefixOperation(at(1, 1), "true", "-")},
+		{"1 / 0", divisionByZeroErrorMsg(at(1, 3))},
+		{"monkeySay x = 5; x /= 0", divisionByZeroErrorMsg(at(1, 20))},
+		{"99999999999999999999 / (1 - 1)", divisionByZeroErrorMsg(at(1, 22))},
+		{"1.5 / 0", divisionByZeroErrorMsg(at(1, 5))},
+		{"1 / 0.0", divisionByZeroErrorMsg(at(1, 3))},
+		{`1.5 + "a"`, invalidInfixOperation(at(1, 5), "1.5", "a", "+")},
+		{"{1.5: 1}", unhashableKeyErrorMsg(at(1, 1), "1.5")},
+		{"(1 + missing) * 2", wrongIdentifierErrorMsg(at(1, 6), "missing")},
+		{"if (missing) { 1 }", wrongIdentifierErrorMsg(at(1, 5), "missing")},
+		{"while (true) { missing }", wrongIdentifierErrorMsg(at(1, 16), "missing")},
+		{"if (false) { 1 } else if (missing) { 2 }", wrongIdentifierErrorMsg(at(1, 27), "missing")},
+		{"match (3) { 1 => 1, 2 => 2 }", noMatchingArmErrorMsg(at(1, 1), "3")},
+		{`monkeySay x = 1; x + match ("a") { "b" => 1 }`, noMatchingArmErrorMsg(at(1, 22), "a")},
+		{"match (missing) { _ => 1 }", wrongIdentifierErrorMsg(at(1, 8), "missing")},
+		{"match (1) { 1 => missing }", wrongIdentifierErrorMsg(at(1, 18), "missing")},
+		{"x = 1", undeclaredAssignmentErrorMsg(at(1, 1), "x")},
+		{"len = 1", undeclaredAssignmentErrorMsg(at(1, 1), "len")},
+		{"x += missing", undeclaredAssignmentErrorMsg(at(1, 1), "x")},
+		{"monkeySay x = 1; x = missing", wrongIdentifierErrorMsg(at(1, 22), "missing")},
+		{"monkeyDo() { y++ }()", undeclaredAssignmentErrorMsg(at(1, 14), "y")},
+		{"monkeySay b = true; b++", invalidInfixOperation(at(1, 22), "true", "1", "+")},
+		{`monkeySay s = "a"; s -= "b"`, invalidInfixOperation(at(1, 22), "a", "b", "-")},
+		{"true && missing", wrongIdentifierErrorMsg(at(1, 9), "missing")},
+		{"monkeySay f = monkeyDo(x) { x + true }; f(1)", invalidInfixOperation(at(1, 31), "1", "true", "+")},
+		{"monkeySay f = monkeyDo(x) { x }; f(missing)", wrongIdentifierErrorMsg(at(1, 36), "missing")},
+		{"monkeySay f = monkeyDo(x) { x }; f(1, 2)", wrongArgumentCountErrorMsg(at(1, 35), "f", 1, 2)},
+		{"monkeySay f = monkeyDo(x) { return missing }; f(1) + 1", wrongIdentifierErrorMsg(at(1, 36), "missing")},
+		{"monkeySay f = monkeyDo() { monkeyDo() { missing } }; f()()", wrongIdentifierErrorMsg(at(1, 41), "missing")},
+		{"[1, 2, 3][3]", indexOutOfRangeErrorMsg(at(1, 10), 3, 3)},
+		{"[1, 2, 3][-1]", indexOutOfRangeErrorMsg(at(1, 10), -1, 3)},
+		{"[1, 2, 3][true]", invalidIndexOperation(at(1, 10), "[1, 2, 3]", "true")},
+		{"5[0]", invalidIndexOperation(at(1, 2), "5", "0")},
+		{"[missing]", wrongIdentifierErrorMsg(at(1, 2), "missing")},
+		{"first([])", builtinErrorMsg(at(1, 6), "first", fmt.Errorf("array is empty"))},
+		{"len(1)", builtinErrorMsg(at(1, 4), "len", fmt.Errorf("argument of type INTEGER is not supported"))},
+		{"push([1])", builtinErrorMsg(at(1, 5), "push", fmt.Errorf("expected 2 arguments, got 1"))},
+		{"rest(1)", builtinErrorMsg(at(1, 5), "rest", fmt.Errorf("first argument must be ARRAY, got INTEGER"))},
+		{"monkeySay m = {[1]: 2}", unhashableKeyErrorMsg(at(1, 15), "[1]")},
+		{`{"a": 1}[[1]]`, unhashableKeyErrorMsg(at(1, 9), "[1]")},
 	}
 
 	for _, tt := range tests {
@@ -55,7 +111,7 @@ func TestEvalErrors(t *testing.T) {
 		}
 
 		if err.Error() != tt.errorMsg {
-			t.Fatalf(fmt.Sprintf("Wrong error message. Expected '%s', Got '%s'", tt.errorMsg, err.Error()))
+			t.Fatalf("Wrong error message. Expected '%s', Got '%s'", tt.errorMsg, err.Error())
 		}
 	}
 }
@@ -67,6 +123,11 @@ func TestEvalInteger(t *testing.T) {
 	}{
 		{"5", 5},
 		{"15", 15},
+		{"10 - 5 - 2", 3},
+		{"16 / 4 / 2", 2},
+		{"-[3][0] + 1", -2},
+		{"-len([1, 2]) + 3", 1},
+		{"monkeySay a = 0; monkeySay b = 0; a = b = 2; a + b", 4},
 	}
 
 	for _, tt := range tests {
@@ -97,6 +158,522 @@ func TestRecursive(t *testing.T) {
 
 }
 
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10; 9", 10},
+		{"1; return 2 * 5; 9", 10},
+		{"if (1 < 2) { if (2 < 3) { return 10 } return 1 } return 2", 10},
+		{"monkeySay f = monkeyDo(x) { if (x > 1) { return 1; } return 2; }; f(5)", 1},
+		{"monkeySay f = monkeyDo(x) { if (x > 1) { return 1; } return 2; }; f(0)", 2},
+		{"monkeySay f = monkeyDo() { return 1; }; f() + 5", 6},
+		{`monkeySay outer = monkeyDo() {
+					monkeySay inner = monkeyDo() { return 1; };
+					inner();
+					return 3;
+				};
+				outer()`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"monkeySay i = 0; while (i < 10) { monkeySay i = i + 1 }; i", 10},
+		{"monkeySay sum = 0; for (monkeySay i = 1; i <= 4; monkeySay i = i + 1) { sum = sum + i }; sum", 10},
+		{"monkeySay i = 0; while (true) { if (i == 3) { break } monkeySay i = i + 1 }; i", 3},
+		{"monkeySay i = 0; for (;;) { i = i + 1; if (i > 5) { break } }; i", 6},
+		{`monkeySay odd = 0;
+				for (monkeySay i = 0; i < 10; monkeySay i = i + 1) { if (i < 5) { continue } odd = odd + 1 };
+				odd`, 5},
+		{`monkeySay count = 0;
+				for (monkeySay i = 0; i < 3; monkeySay i = i + 1) {
+					for (monkeySay j = 0; j < 3; monkeySay j = j + 1) { if (j == 1) { break } count = count + 1 }
+				};
+				count`, 3},
+		{`monkeySay find = monkeyDo(a, x) {
+					for (monkeySay i = 0; i < len(a); monkeySay i = i + 1) { if (a[i] == x) { return i } }
+					return -1
+				};
+				find([4, 5, 6], 6)`, 2},
+		{"monkeySay f = monkeyDo() { while (true) { if (true) { return 7 } } }; f()", 7},
+		{"monkeySay n = 0; for (monkeySay i = 0; i < 6; i++) { n += match (i) { 2 => { continue }, 4 => { break }, _ => i } }; n", 4},
+		{"monkeySay n = 0; while (true) { n++; while (if (n > 2) { break } else { false }) { } }; n", 3},
+		{"monkeySay f = monkeyDo(x) { [1, if (x) { return 5 } else { 2 }] }; f(true)", 5},
+		{"monkeySay f = monkeyDo(x) { len([1, if (x) { return 5 } else { 2 }]) }; f(false)", 2},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+
+	if result := evaluateTest("while (false) { 1 }"); result.Type() != Object.NULL_OBJ {
+		t.Errorf("a loop should evaluate to null, got %s", result.Inspect())
+	}
+}
+
+func TestEvalReassignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"monkeySay x = 1; x = x + 1; x", 2},
+		{"monkeySay x = 1; x = 5", 5},
+		{"monkeySay x = 1; monkeySay y = 2; x = y = 7; x + y", 14},
+		{"monkeySay x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"monkeySay x = 1; x++", 1},
+		{"monkeySay x = 1; x++; x", 2},
+		{"monkeySay x = 1; ++x", 2},
+		{"monkeySay x = 1; x--; --x", -1},
+		{"monkeySay x = 1; monkeySay f = monkeyDo() { x = 2 }; f(); x", 2},
+		{"monkeySay x = 1; monkeySay f = monkeyDo(x) { x = 2 }; f(0); x", 1},
+		{"monkeySay x = 1; monkeySay f = monkeyDo() { monkeySay x = 5; x = 2 }; f(); x", 1},
+		{`monkeySay counter = monkeyDo() {
+					monkeySay count = 0;
+					monkeyDo() { count++; count }
+				};
+				monkeySay next = counter();
+				next(); next(); next()`, 3},
+		{`monkeySay f = monkeyDo() {
+					monkeySay x = 1;
+					monkeySay get = monkeyDo() { x };
+					x = 10;
+					get()
+				};
+				f()`, 10},
+		{"monkeySay sum = 0; for (monkeySay i = 0; i < 5; i++) { sum += i }; sum", 10},
+		{"monkeySay i = 10; for (monkeySay i = 0; i < 3; i++) { }; i", 10},
+		{"monkeySay i = 0; while (i < 7) { i += 2 }; i", 8},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+
+	testString(t, evaluateTest(`monkeySay s = "a"; s += "b"; s`), "ab")
+}
+
+func TestEvalLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"!(1 > 2) && true", true},
+		{"false && missing", false},
+		{"true || missing", true},
+		{"false && 1", false},
+		{"monkeySay calls = 0; monkeySay f = monkeyDo() { calls++; true }; false && f(); true || f(); calls == 0", true},
+		{"monkeySay calls = 0; monkeySay f = monkeyDo() { calls++; true }; true && f(); false || f(); calls == 2", true},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testBoolean(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalTruthiness(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!0", true},
+		{"!1", false},
+		{"!-1", false},
+		{`!""`, true},
+		{`!"a"`, false},
+		{"![]", true},
+		{"![0]", false},
+		{"!{}", true},
+		{"!{1: 2}", false},
+		{"!len", false},
+		{"!monkeyDo() { }", false},
+		{"!(monkeyDo() { })()", true},
+		{"if (1) { true } else { false }", true},
+		{"if (0) { true } else { false }", false},
+		{`if ("") { true } else { false }`, false},
+		{"if ([1]) { true } else { false }", true},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testBoolean(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalLogicalOperands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1 && 2", 2},
+		{"0 && 2", 0},
+		{"0 || 5", 5},
+		{"3 || missing", 3},
+		{"monkeySay x = 0; x || 10", 10},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+
+	testString(t, evaluateTest(`"" || "default"`), "default")
+}
+
+func TestEvalBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"0 - 9223372036854775807 - 2", "-9223372036854775809"},
+		{"4611686018427387904 * 2", "9223372036854775808"},
+		{"-4611686018427387904 * 2", "-9223372036854775808"},
+		{"monkeySay min = -9223372036854775807 - 1; -min", "9223372036854775808"},
+		{"monkeySay min = -9223372036854775807 - 1; min / -1", "9223372036854775808"},
+		{"monkeySay min = -9223372036854775807 - 1; min * -1", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"-123456789012345678901234567890 / 10", "-12345678901234567890123456789"},
+		{"monkeySay fact = monkeyDo(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)", "15511210043330985984000000"},
+		{"99999999999999999999 > 9223372036854775807", "true"},
+		{"(9223372036854775807 + 1) == 9223372036854775808", "true"},
+		{"{9223372036854775808: 1}[9223372036854775807 + 1]", "1"},
+		{`type(99999999999999999999)`, "INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		if evaluatedProgramme == nil || evaluatedProgramme.Inspect() != tt.expected {
+			t.Fatalf("%q: expected %s, got %v", tt.input, tt.expected, evaluatedProgramme)
+		}
+	}
+
+	demoted := evaluateTest("(9223372036854775807 + 10) - 20")
+	if demoted != (Object.Integer{Value: 9223372036854775797}) {
+		t.Fatalf("expected a result within int64 to be an Integer, got %T %v", demoted, demoted)
+	}
+}
+
+func TestEvalFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"2.0", "2.0"},
+		{"1e9", "1000000000.0"},
+		{"1e21", "1e+21"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1 + 0.5", "1.5"},
+		{"3 / 2.0", "1.5"},
+		{"3 / 2", "1"},
+		{"-2.5 * 2", "-5.0"},
+		{"0xff + 0b1 + 0o7", "263"},
+		{"1_000 * 1_000", "1000000"},
+		{"1 == 1.0", "true"},
+		{"1.5 != 1", "true"},
+		{"2 < 2.5", "true"},
+		{"2.5 >= 3", "false"},
+		{"9223372036854775808 * 0.5", "4611686018427388000.0"},
+		{"!0.0", "true"},
+		{"if (0.1) { 1 } else { 2 }", "1"},
+		{"type(1.5)", "FLOAT"},
+		{"1e308 * 10", "+Inf"},
+		{"match (1.0) { 1 => \"one\", _ => \"other\" }", "one"},
+		{"monkeySay x = 1; x += 0.5; x", "1.5"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		if evaluatedProgramme == nil || evaluatedProgramme.Inspect() != tt.expected {
+			t.Fatalf("%q: expected %s, got %v", tt.input, tt.expected, evaluatedProgramme)
+		}
+	}
+}
+
+func TestEvalNull(t *testing.T) {
+	tests := []string{
+		"",
+		"if (1 > 2) { 5 }",
+		"(monkeyDo() { })()",
+	}
+
+	for _, input := range tests {
+		evaluatedProgramme := evaluateTest(input)
+
+		if _, ok := evaluatedProgramme.(Object.Null); !ok {
+			t.Errorf("expected null for %q, got %T", input, evaluatedProgramme)
+		}
+	}
+}
+
+func TestEvalArray(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[]", "[]"},
+		{"rest([1, 2, 3])", "[2, 3]"},
+		{"rest([])", "[]"},
+		{"monkeySay a = [1]; push(a, 2); a", "[1]"},
+		{"push([1], 2)", "[1, 2]"},
+		{"[[1, 2], [3]]", "[[1, 2], [3]]"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		if _, ok := evaluatedProgramme.(Object.Array); !ok {
+			t.Fatalf("Object is not array, is %T", evaluatedProgramme)
+		}
+		if evaluatedProgramme.Inspect() != tt.expected {
+			t.Errorf("inspect didn't match, expected: %s, got: %s", tt.expected, evaluatedProgramme.Inspect())
+		}
+	}
+}
+
+func TestArrayIndexAndBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1 + 1]", 3},
+		{"monkeySay a = [1, 2, 3]; a[0] + a[2]", 4},
+		{"[[1, 2], [3]][0][1]", 2},
+		{"len([1, 2, 3])", 3},
+		{"len([])", 0},
+		{`len("four")`, 4},
+		{"first([7, 8])", 7},
+		{"last([7, 8])", 8},
+		{`monkeySay sum = monkeyDo(a) { if (len(a) == 0) { return 0 } return first(a) + sum(rest(a)) };
+				sum([1, 2, 3, 4])`, 10},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalMap(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 2, "a": 1}`, "{a: 1, b: 2}"},
+		{`{1: true, true: "yes", "1": 1}`, "{1: 1, 1: true, true: yes}"},
+		{`monkeySay k = "key"; {k: 1 + 1}`, "{key: 2}"},
+		{`{"a": 1, "a": 2}`, "{a: 2}"},
+		{"monkeySay m = {}; m", "{}"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		if _, ok := evaluatedProgramme.(Object.Map); !ok {
+			t.Fatalf("Object is not map, is %T", evaluatedProgramme)
+		}
+		if evaluatedProgramme.Inspect() != tt.expected {
+			t.Errorf("inspect didn't match, expected: %s, got: %s", tt.expected, evaluatedProgramme.Inspect())
+		}
+	}
+}
+
+func TestMapIndex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`{"a": 1, "b": 2}["b"]`, 2},
+		{`{1: 10, true: 20}[1]`, 10},
+		{`{1: 10, true: 20}[true]`, 20},
+		{`monkeySay m = {"x": [1, 2, 3]}; m["x"][2]`, 3},
+		{`len({"a": 1, "b": 2})`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+
+	if missing := evaluateTest(`{"a": 1}["b"]`); missing.Type() != Object.NULL_OBJ {
+		t.Errorf("missing key should be null, got %s", missing.Inspect())
+	}
+}
+
+func TestOutputBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`puts("hello", 1 + 2)`, "hello\n3\n"},
+		{`monkeySay bar = 1; monkeySay threePlusBar = 3 + bar; put(threePlusBar)`, "4\n"},
+		{`print("a", [1, 2], true); print("!")`, "a [1, 2] true!"},
+		{`monkeySay say = monkeyDo(x) { puts(x) }; say("from a function")`, "from a function\n"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := Parser.New(*l)
+		programme := p.ParseProgramme()
+		env := Object.NewEnvironment(nil)
+		out := bytes.Buffer{}
+		env.SetOutput(&out)
+
+		if _, err := Eval(programme, env); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("output didn't match, expected: %q, got: %q", tt.expected, out.String())
+		}
+	}
+}
+
+func TestTypeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type(1)", "INTEGER"},
+		{`type("a")`, "STRING"},
+		{"type([])", "ARRAY"},
+		{"type(monkeyDo() {})", "FUNCTION"},
+		{"type(len)", "BUILTIN"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testString(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestArgsBuiltin(t *testing.T) {
+	l := Lexer.New(`args()`)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+	env := Object.NewEnvironment(nil)
+	env.SetArgs([]string{"one", "two"})
+
+	evaluated, err := Eval(programme, env)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if evaluated.Inspect() != "[one, two]" {
+		t.Errorf("expected the arguments, got %s", evaluated.Inspect())
+	}
+
+	if empty := evaluateTest("args()"); empty.Inspect() != "[]" {
+		t.Errorf("expected no arguments, got %s", empty.Inspect())
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	RegisterBuiltin("double", func(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+		return Object.Integer{Value: args[0].(Object.Integer).Value * 2}, nil
+	})
+	defer delete(builtins, "double")
+
+	testInteger(t, evaluateTest("double(21)"), 42)
+	testInteger(t, evaluateTest("monkeySay double = monkeyDo(x) { x }; double(21)"), 21)
+}
+
+func TestEvalLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input    string
+		ctx      context.Context
+		limits   Limits
+		expected error
+	}{
+		{"monkeySay f = monkeyDo(x) { f(x + 1) }; f(0)", context.Background(), DefaultLimits, ErrCallDepthLimitExceeded},
+		{"monkeySay f = monkeyDo(x) { f(x + 1) }; f(0)", context.Background(), Limits{MaxCallDepth: 10}, ErrCallDepthLimitExceeded},
+		{"monkeySay f = monkeyDo(x) { f(x + 1) }; f(0)", context.Background(), Limits{MaxSteps: 1000}, ErrStepLimitExceeded},
+		{"1 + 2", cancelled, DefaultLimits, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := Parser.New(*l)
+		programme := p.ParseProgramme()
+		env := Object.NewEnvironment(nil)
+
+		_, err := EvalContext(tt.ctx, programme, env, tt.limits)
+
+		var limitError LimitError
+		if !errors.As(err, &limitError) {
+			t.Fatalf("expected a LimitError for %q, got %v", tt.input, err)
+		}
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("expected %v for %q, got %v", tt.expected, tt.input, err)
+		}
+	}
+}
+
+func TestEvalDeadline(t *testing.T) {
+	input := `monkeySay fib = monkeyDo(n) { if (n < 2) { return n } return fib(n - 1) + fib(n - 2) }; fib(40)`
+
+	l := Lexer.New(input)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := EvalContext(ctx, programme, Object.NewEnvironment(nil), DefaultLimits)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to stop evaluation, got %v", err)
+	}
+}
+
+func TestEvalWithinLimits(t *testing.T) {
+	input := "monkeySay f = monkeyDo(x) { if (x == 0) { return 0 } return f(x - 1) }; f(50)"
+
+	l := Lexer.New(input)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+
+	obj, err := EvalContext(context.Background(), programme, Object.NewEnvironment(nil), Limits{MaxSteps: 10000, MaxCallDepth: 51})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testInteger(t, obj, 0)
+}
+
 func TestEvalBool(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -120,6 +697,40 @@ func TestEvalIfStatement(t *testing.T) {
 	}{
 		{"if (1 < 3) { return 5 }", 5},
 		{"if (2 != 1 + 1) { return 3 } else { return 10 }", 10},
+		{"monkeySay a = 3; monkeySay b = 7; monkeySay x = if (a > b) { a } else { b }; x", 7},
+		{"1 + if (true) { 2 } else { 3 } * 2", 5},
+		{"monkeySay abs = monkeyDo(n) { if (n < 0) { -n } else { n } }; abs(-4)", 4},
+		{"[if (false) { 1 } else { 2 }][0]", 2},
+		{"monkeySay i = 3; monkeySay n = 0; while (i) { i--; n++ }; n", 3},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testInteger(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalElseIfAndMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"monkeySay x = 5; if (x < 3) { 1 } else if (x < 6) { 2 } else { 3 }", 2},
+		{"monkeySay x = 9; if (x < 3) { 1 } else if (x < 6) { 2 } else { 3 }", 3},
+		{"monkeySay sign = monkeyDo(n) { if (n < 0) { return -1 } else if (n == 0) { return 0 } 1 }; sign(-5) + sign(0) * 10 + sign(7) * 100", 99},
+		{"match (2) { 1 => 10, 2 => 20, _ => 30 }", 20},
+		{"match (3) { 1 => 10, 2 => 20, _ => 30 }", 30},
+		{"match (-1) { -1 => 5, _ => 6 }", 5},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"match (1 < 2) { false => 0, true => 1 }", 1},
+		{"match (4) { 1 => 0, n => n * 2 }", 8},
+		{"monkeySay n = 1; match (4) { n => n }; n", 1},
+		{"match (4) { 4 => { monkeySay n = 2 } }; monkeySay n = 1; n", 1},
+		{"match (1) { 1 => { monkeySay y = 3; y + 1 }, _ => 0 }", 4},
+		{`match ("1") { 1 => 1, _ => 2 }`, 2},
+		{"monkeySay f = monkeyDo(n) { match (n) { 0 => { return 100 }, _ => n } }; f(0) + f(5)", 105},
+		{"monkeySay fib = monkeyDo(n) { match (n) { 0 => 0, 1 => 1, _ => fib(n - 1) + fib(n - 2) } }; fib(10)", 55},
 	}
 
 	for _, tt := range tests {
@@ -172,6 +783,79 @@ func TestInfixBoolean(t *testing.T) {
 	}
 }
 
+func TestEvalEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true == true", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"monkeySay x = 1 < 2; x == true", true},
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{"1 == true", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1, 3]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[] == []", true},
+		{`{"a": 1, 2: [3]} == {2: [3], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{"monkeySay f = monkeyDo() { 1 }; f == f", true},
+		{"monkeySay f = monkeyDo() { 1 }; monkeySay g = f; f == g", true},
+		{"monkeyDo() { 1 } == monkeyDo() { 1 }", false},
+		{"len == len", true},
+		{"len == first", false},
+		{"(monkeyDo() {})() == (monkeyDo() {})()", true},
+		{"(monkeyDo() {})() == false", false},
+		{`"a" < "b"`, true},
+		{`"b" <= "a"`, false},
+		{`"abc" >= "abc"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testBoolean(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"`, "hello"},
+		{`"hello " + "world"`, "hello world"},
+		{`monkeySay greet = monkeyDo(text) { return "hello " + text }; greet("world")`, "hello world"},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testString(t, evaluatedProgramme, tt.expected)
+	}
+}
+
+func TestInfixString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" + "b" == "ab"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluatedProgramme := evaluateTest(tt.input)
+
+		testBoolean(t, evaluatedProgramme, tt.expected)
+	}
+}
+
 func TestInfixInteger(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -260,6 +944,20 @@ func testBoolean(t *testing.T, evaluatedProgramme Object.Object, expected bool)
 	}
 }
 
+func testString(t *testing.T, evaluatedProgramme Object.Object, expected string) {
+	stringObject, ok := evaluatedProgramme.(Object.String)
+	if !ok {
+		t.Errorf("Object is not string, is %T", evaluatedProgramme)
+	}
+	if stringObject.Value != expected {
+		t.Errorf("object has wrong value, expected %q, got %q", expected, stringObject.Value)
+	}
+}
+
+func at(line, column int) Token.Position {
+	return Token.Position{Line: line, Column: column}
+}
+
 func evaluateTest(input string) Object.Object {
 	l := Lexer.New(input)
 	p := Parser.New(*l)
@@ -0,0 +1,70 @@
+package Evaluator
+
+import (
+	"Chimp/Token"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStepLimitExceeded      = errors.New("step limit exceeded")
+	ErrCallDepthLimitExceeded = errors.New("call depth limit exceeded")
+)
+
+// Limits bounds the work a single evaluation may do. A zero field means no limit.
+type Limits struct {
+	// MaxSteps is the number of AST nodes that may be evaluated.
+	MaxSteps int
+	// MaxCallDepth is the number of nested function calls, which keeps runaway
+	// recursion from overflowing the Go stack.
+	MaxCallDepth int
+}
+
+var DefaultLimits = Limits{MaxCallDepth: 10000}
+
+// LimitError is returned when evaluation is stopped before it finishes. Reason is
+// ErrStepLimitExceeded, ErrCallDepthLimitExceeded or the error of the context.
+type LimitError struct {
+	Position Token.Position
+	Reason   error
+}
+
+func (e LimitError) Error() string {
+	if e.Position.Line == 0 {
+		return fmt.Sprintf("Evaluation stopped: %s", e.Reason)
+	}
+	return fmt.Sprintf("%s: Evaluation stopped: %s", e.Position, e.Reason)
+}
+
+func (e LimitError) Unwrap() error {
+	return e.Reason
+}
+
+// contextCheckInterval is how many steps pass between checks of the context, which
+// are comparatively expensive.
+const contextCheckInterval = 256
+
+func (e *evaluator) step() error {
+	if e.steps%contextCheckInterval == 0 {
+		if err := e.ctx.Err(); err != nil {
+			return LimitError{Reason: err}
+		}
+	}
+	e.steps++
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		return LimitError{Reason: ErrStepLimitExceeded}
+	}
+	return nil
+}
+
+func (e *evaluator) enterCall(pos Token.Position) error {
+	if e.limits.MaxCallDepth > 0 && e.depth >= e.limits.MaxCallDepth {
+		return LimitError{Position: pos, Reason: ErrCallDepthLimitExceeded}
+	}
+	e.depth++
+	return nil
+}
+
+func (e *evaluator) exitCall() {
+	e.depth--
+}
@@ -0,0 +1,105 @@
+package Interpreter
+
+import (
+	"Chimp/Object"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// ToObject converts a Go value to a Chimp object. Integers, including *big.Int,
+// floats, booleans and strings map to their Chimp counterparts, slices and arrays
+// to Object.Array, maps with hashable keys to Object.Map and nil to Object.Null.
+// Values that already are Chimp objects are returned unchanged.
+func ToObject(value interface{}) (Object.Object, error) {
+	if value == nil {
+		return Object.Null{}, nil
+	}
+	if object, ok := value.(Object.Object); ok {
+		return object, nil
+	}
+	if integer, ok := value.(*big.Int); ok {
+		return Object.NewInteger(new(big.Int).Set(integer)), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Uint64:
+		return Object.NewInteger(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return Object.Float{Value: v.Float()}, nil
+	case reflect.Bool:
+		return Object.Boolean{Value: v.Bool()}, nil
+	case reflect.String:
+		return Object.String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]Object.Object, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			element, err := ToObject(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return Object.Array{Elements: elements}, nil
+	case reflect.Map:
+		pairs := make(map[Object.HashKey]Object.MapPair, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := ToObject(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			hashable, ok := key.(Object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("cannot use %T as a map key", iter.Key().Interface())
+			}
+			value, err := ToObject(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			pairs[hashable.HashKey()] = Object.MapPair{Key: key, Value: value}
+		}
+		return Object.Map{Pairs: pairs}, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to a Chimp value", value)
+}
+
+// FromObject converts a Chimp object to a Go value: int64, *big.Int for integers
+// beyond int64, float64, bool, string, []interface{}, map[interface{}]interface{}
+// or nil. Functions and other objects without a Go counterpart are returned as
+// they are.
+func FromObject(object Object.Object) interface{} {
+	switch object := object.(type) {
+	case Object.Integer:
+		return object.Value
+	case Object.BigInteger:
+		return new(big.Int).Set(object.Value)
+	case Object.Float:
+		return object.Value
+	case Object.Boolean:
+		return object.Value
+	case Object.String:
+		return object.Value
+	case Object.Null:
+		return nil
+	case Object.Array:
+		values := make([]interface{}, len(object.Elements))
+		for i, element := range object.Elements {
+			values[i] = FromObject(element)
+		}
+		return values
+	case Object.Map:
+		values := make(map[interface{}]interface{}, len(object.Pairs))
+		for _, pair := range object.Pairs {
+			values[FromObject(pair.Key)] = FromObject(pair.Value)
+		}
+		return values
+	}
+	return object
+}
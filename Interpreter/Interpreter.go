@@ -0,0 +1,145 @@
+package Interpreter
+
+import (
+	"Chimp/Evaluator"
+	"Chimp/Lexer"
+	"Chimp/Object"
+	"Chimp/Parser"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Interpreter runs Chimp source for a Go host program. Globals defined by one Run
+// stay visible to the next, so a script can be loaded once and its functions called
+// repeatedly with CallFunction.
+type Interpreter struct {
+	env    *Object.Environment
+	limits Evaluator.Limits
+}
+
+// ParseError holds every error the parser reported for a source.
+type ParseError struct {
+	Errors []string
+}
+
+func (e ParseError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+func New() *Interpreter {
+	return &Interpreter{env: Object.NewEnvironment(nil), limits: Evaluator.DefaultLimits}
+}
+
+// SetLimits bounds every later Run and CallFunction, Evaluator.DefaultLimits by default.
+func (i *Interpreter) SetLimits(limits Evaluator.Limits) {
+	i.limits = limits
+}
+
+// SetOutput sets where builtins such as puts write, os.Stdout by default.
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.env.SetOutput(w)
+}
+
+// SetArgs sets the command-line arguments that scripts read with the args builtin.
+func (i *Interpreter) SetArgs(args []string) {
+	i.env.SetArgs(args)
+}
+
+// RegisterBuiltin makes a Go function callable under name by the programmes this
+// interpreter runs, and no others.
+func (i *Interpreter) RegisterBuiltin(name string, fn Object.BuiltinFunction) {
+	i.env.SetBuiltin(name, fn)
+}
+
+// Environment returns the global scope that programmes are evaluated in.
+func (i *Interpreter) Environment() *Object.Environment {
+	return i.env
+}
+
+// Run parses and evaluates source, returning the value of the programme. Nothing is
+// evaluated when the source has parse errors, which are returned as a ParseError.
+func (i *Interpreter) Run(source string) (Object.Object, error) {
+	return i.RunContext(context.Background(), "", source)
+}
+
+// RunFile is Run with the file name used in error positions.
+func (i *Interpreter) RunFile(fileName string, source string) (Object.Object, error) {
+	return i.RunContext(context.Background(), fileName, source)
+}
+
+// RunContext is RunFile stopping with an Evaluator.LimitError when ctx is done.
+func (i *Interpreter) RunContext(ctx context.Context, fileName string, source string) (Object.Object, error) {
+	lexer := Lexer.NewWithFileName(fileName, source)
+	parser := Parser.New(*lexer)
+
+	programme := parser.ParseProgramme()
+
+	if errors := parser.GetErrors(); len(errors) > 0 {
+		return nil, ParseError{Errors: errors}
+	}
+
+	return Evaluator.EvalContext(ctx, programme, i.env, i.limits)
+}
+
+// SetGlobal binds a Go value, converted with ToObject, to name in the global scope.
+func (i *Interpreter) SetGlobal(name string, value interface{}) error {
+	object, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+	i.env.Set(name, object)
+	return nil
+}
+
+// GetGlobal returns the value bound to name converted with FromObject.
+func (i *Interpreter) GetGlobal(name string) (interface{}, bool) {
+	object, ok := i.env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return FromObject(object), true
+}
+
+// CallFunction calls the Chimp function or builtin bound to name, converting args
+// with ToObject and the result with FromObject.
+func (i *Interpreter) CallFunction(name string, args ...interface{}) (interface{}, error) {
+	return i.CallFunctionContext(context.Background(), name, args...)
+}
+
+// CallFunctionContext is CallFunction stopping with an Evaluator.LimitError when ctx is done.
+func (i *Interpreter) CallFunctionContext(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	function, ok := i.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("function '%s' is not defined", name)
+	}
+
+	objects := make([]Object.Object, len(args))
+	for j, arg := range args {
+		object, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", j, err)
+		}
+		objects[j] = object
+	}
+
+	result, err := Evaluator.ApplyFunctionContext(ctx, i.limits, function, i.env, objects...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return FromObject(result), nil
+}
+
+func (i *Interpreter) lookup(name string) (Object.Object, bool) {
+	if object, ok := i.env.Get(name); ok {
+		return object, true
+	}
+	if builtin, ok := i.env.Builtin(name); ok {
+		return builtin, true
+	}
+	if builtin, ok := Evaluator.LookupBuiltin(name); ok {
+		return builtin, true
+	}
+	return nil, false
+}
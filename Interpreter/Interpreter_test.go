@@ -0,0 +1,235 @@
+package Interpreter
+
+import (
+	"Chimp/Evaluator"
+	"Chimp/Object"
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	interpreter := New()
+
+	if _, err := interpreter.Run("monkeySay double = monkeyDo(x) { x * 2 };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interpreter.Run("double(21)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result.Inspect() != "42" {
+		t.Fatalf("expected 42, got %s", result.Inspect())
+	}
+}
+
+func TestRunParseError(t *testing.T) {
+	interpreter := New()
+
+	_, err := interpreter.RunFile("script.chimp", "monkeySay = 1;\nmonkeySay 2 = 1;")
+
+	parseError, ok := err.(ParseError)
+	if !ok {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+
+	expected := []string{
+		"script.chimp:1:11: expected IDENT, but received '='",
+		"script.chimp:2:11: expected IDENT, but received '2'",
+	}
+	if !reflect.DeepEqual(parseError.Errors, expected) {
+		t.Fatalf("expected errors %v, got %v", expected, parseError.Errors)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	interpreter := New()
+
+	if err := interpreter.SetGlobal("limit", 10); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := interpreter.SetGlobal("names", []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := interpreter.SetGlobal("rate", 0.5); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := interpreter.Run(`monkeySay total = limit + len(names); monkeySay both = names[0] + names[1]; monkeySay half = limit * rate;`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected interface{}
+	}{
+		{"total", int64(12)},
+		{"both", "ab"},
+		{"half", 5.0},
+		{"names", []interface{}{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		value, ok := interpreter.GetGlobal(tt.name)
+		if !ok {
+			t.Fatalf("global %s not found", tt.name)
+		}
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Fatalf("global %s: expected %#v, got %#v", tt.name, tt.expected, value)
+		}
+	}
+
+	if _, ok := interpreter.GetGlobal("missing"); ok {
+		t.Fatalf("expected missing global not to be found")
+	}
+
+	if err := interpreter.SetGlobal("bad", complex(1, 2)); err == nil {
+		t.Fatalf("expected an error converting a complex number")
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	interpreter := New()
+	out := bytes.Buffer{}
+	interpreter.SetOutput(&out)
+
+	_, err := interpreter.Run(`
+		monkeySay greet = monkeyDo(name, times) {
+			puts("hello " + name);
+			return {"name": name, "times": times * 2, "ok": true};
+		};
+		monkeySay notAFunction = 1;
+	`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interpreter.CallFunction("greet", "world", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[interface{}]interface{}{"name": "world", "times": int64(4), "ok": true}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+	if out.String() != "hello world\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	length, err := interpreter.CallFunction("len", []int{1, 2, 3})
+	if err != nil || length != int64(3) {
+		t.Fatalf("expected builtin len to return 3, got %v (%v)", length, err)
+	}
+
+	errorCases := []struct {
+		name string
+		args []interface{}
+	}{
+		{"missing", nil},
+		{"notAFunction", nil},
+		{"greet", []interface{}{"too few"}},
+		{"greet", []interface{}{struct{}{}, 1}},
+	}
+
+	for _, tt := range errorCases {
+		if _, err := interpreter.CallFunction(tt.name, tt.args...); err == nil {
+			t.Fatalf("expected an error calling %s with %v", tt.name, tt.args)
+		}
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	interpreter, other := New(), New()
+	interpreter.RegisterBuiltin("double", func(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+		return Object.Integer{Value: args[0].(Object.Integer).Value * 2}, nil
+	})
+
+	result, err := interpreter.Run("double(21)")
+	if err != nil || result.Inspect() != "42" {
+		t.Fatalf("expected 42, got %v (%v)", result, err)
+	}
+	if doubled, err := interpreter.CallFunction("double", 4); err != nil || doubled != int64(8) {
+		t.Fatalf("expected 8, got %v (%v)", doubled, err)
+	}
+
+	if _, err := other.Run("double(21)"); err == nil {
+		t.Fatalf("expected double to be unknown to another interpreter")
+	}
+}
+
+func TestRunConcurrently(t *testing.T) {
+	done := make(chan error)
+	for j := 0; j < 4; j++ {
+		go func() {
+			_, err := New().Run("len([1, 2, 3]) + len(\"abc\")")
+			done <- err
+		}()
+	}
+	Evaluator.RegisterBuiltin("registeredWhileRunning", func(env *Object.Environment, args ...Object.Object) (Object.Object, error) {
+		return Object.Null{}, nil
+	})
+
+	for j := 0; j < 4; j++ {
+		if err := <-done; err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	interpreter := New()
+	interpreter.SetLimits(Evaluator.Limits{MaxCallDepth: 5})
+
+	if _, err := interpreter.Run("monkeySay down = monkeyDo(n) { if (n == 0) { return 0 } return down(n - 1) };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := interpreter.CallFunction("down", 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := interpreter.CallFunction("down", 10); !errors.Is(err, Evaluator.ErrCallDepthLimitExceeded) {
+		t.Fatalf("expected the call depth limit to be exceeded, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := interpreter.RunContext(ctx, "", "down(1)"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled run, got %v", err)
+	}
+}
+
+func TestObjectConversion(t *testing.T) {
+	object, err := ToObject(map[string][]bool{"flags": {true, false}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if object.Inspect() != "{flags: [true, false]}" {
+		t.Fatalf("unexpected conversion %s", object.Inspect())
+	}
+
+	null, _ := ToObject(nil)
+	if _, ok := null.(Object.Null); !ok {
+		t.Fatalf("expected nil to convert to null, got %T", null)
+	}
+	if FromObject(Object.Null{}) != nil {
+		t.Fatalf("expected null to convert to nil")
+	}
+
+	large, _ := ToObject(uint64(1) << 63)
+	if _, ok := large.(Object.BigInteger); !ok || large.Inspect() != "9223372036854775808" {
+		t.Fatalf("expected a big integer, got %T %s", large, large.Inspect())
+	}
+	if value, ok := FromObject(large).(*big.Int); !ok || value.String() != "9223372036854775808" {
+		t.Fatalf("expected a *big.Int, got %T", FromObject(large))
+	}
+	small, _ := ToObject(big.NewInt(42))
+	if small != (Object.Integer{Value: 42}) {
+		t.Fatalf("expected a small *big.Int to convert to an integer, got %T", small)
+	}
+}
@@ -2,6 +2,10 @@ package Lexer
 
 import (
 	"Chimp/Token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 var keywords = map[string]Token.TokenType{
@@ -10,20 +14,50 @@ var keywords = map[string]Token.TokenType{
 	"if":        Token.IF,
 	"else":      Token.ELSE,
 	"return":    Token.RETURN,
+	"while":     Token.WHILE,
+	"for":       Token.FOR,
+	"break":     Token.BREAK,
+	"continue":  Token.CONTINUE,
+	"match":     Token.MATCH,
 	"true":      Token.TRUE,
 	"false":     Token.FALSE,
 }
 
+// Keywords returns the reserved words of the language, sorted.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
 type Lexer struct {
 	input   string
+	file    string
 	readPos int
 	curPos  int
 	ch      byte
+	line    int
+	column  int
+	// doc collects the "///" comments skipped since the last token.
+	doc string
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFileName("", input)
+}
+
+// NewWithFileName creates a Lexer whose token positions report the given file name.
+// A "#!" line at the very start of the input is skipped like a comment, so that
+// scripts can be made executable.
+func NewWithFileName(fileName string, input string) *Lexer {
+	l := &Lexer{input: input, file: fileName, line: 1}
 	l.NextToken()
+	if strings.HasPrefix(input, "#!") {
+		l.skipLineComment()
+	}
 	return l
 }
 
@@ -32,12 +66,18 @@ func (l *Lexer) NextToken() Token.Token {
 	l.skipWhiteSpaces()
 
 	tok := Token.Token{}
+	pos := l.position()
+	doc := strings.TrimSuffix(l.doc, "\n")
+	l.doc = ""
 
 	switch l.ch {
 	case '=':
 		if peekToken := l.peekToken(); peekToken == '=' {
 			tok = newToken(Token.EQ, "==")
 			l.readNextChar()
+		} else if peekToken == '>' {
+			tok = newToken(Token.ARROW, "=>")
+			l.readNextChar()
 		} else {
 			tok = newToken(Token.ASSIGN, "=")
 		}
@@ -45,13 +85,30 @@ func (l *Lexer) NextToken() Token.Token {
 		if peekToken := l.peekToken(); peekToken == '+' {
 			tok = newToken(Token.INCR, "++")
 			l.readNextChar()
+		} else if peekToken == '=' {
+			tok = newToken(Token.PLUS_ASSIGN, "+=")
+			l.readNextChar()
 		} else {
 			tok = newToken(Token.PLUS, "+")
 		}
 	case '*':
-		tok = newToken(Token.MULTIPLY, "*")
+		if peekToken := l.peekToken(); peekToken == '=' {
+			tok = newToken(Token.MULTIPLY_ASSIGN, "*=")
+			l.readNextChar()
+		} else {
+			tok = newToken(Token.MULTIPLY, "*")
+		}
 	case '/':
-		tok = newToken(Token.DIVIDE, "/")
+		if peekToken := l.peekToken(); peekToken == '*' {
+			// skipWhiteSpaces leaves only a block comment that is never closed
+			tok = newToken(Token.ILLEGAL, l.input[l.curPos:])
+			l.readPos = len(l.input)
+		} else if peekToken == '=' {
+			tok = newToken(Token.DIVIDE_ASSIGN, "/=")
+			l.readNextChar()
+		} else {
+			tok = newToken(Token.DIVIDE, "/")
+		}
 	case '!':
 		if peekToken := l.peekToken(); peekToken == '=' {
 			tok = newToken(Token.NEQ, "!=")
@@ -63,9 +120,26 @@ func (l *Lexer) NextToken() Token.Token {
 		if peekToken := l.peekToken(); peekToken == '-' {
 			tok = newToken(Token.DECR, "--")
 			l.readNextChar()
+		} else if peekToken == '=' {
+			tok = newToken(Token.MINUS_ASSIGN, "-=")
+			l.readNextChar()
 		} else {
 			tok = newToken(Token.MINUS, "-")
 		}
+	case '&':
+		if peekToken := l.peekToken(); peekToken == '&' {
+			tok = newToken(Token.AND, "&&")
+			l.readNextChar()
+		} else {
+			tok = newToken(Token.ILLEGAL, "&")
+		}
+	case '|':
+		if peekToken := l.peekToken(); peekToken == '|' {
+			tok = newToken(Token.OR, "||")
+			l.readNextChar()
+		} else {
+			tok = newToken(Token.ILLEGAL, "|")
+		}
 	case '>':
 		if peekToken := l.peekToken(); peekToken == '=' {
 			tok = newToken(Token.GTE, ">=")
@@ -88,22 +162,36 @@ func (l *Lexer) NextToken() Token.Token {
 		tok = newToken(Token.LBRACE, "(")
 	case ')':
 		tok = newToken(Token.RBRACE, ")")
+	case '"':
+		tok = l.readString()
+	case '[':
+		tok = newToken(Token.LBRACKET, "[")
+	case ']':
+		tok = newToken(Token.RBRACKET, "]")
 	case ',':
 		tok = newToken(Token.COMMA, ",")
 	case ';':
 		tok = newToken(Token.SEMICOLON, ";")
+	case ':':
+		tok = newToken(Token.COLON, ":")
 	case 0:
 		tok = newToken(Token.EOF, "EOF")
 	default:
 		if isLetter(l.ch) {
 			word := l.getWord()
 			if keyword := keywords[word]; keyword == "" {
-				return newToken(Token.IDENT, word)
+				tok = newToken(Token.IDENT, word)
 			} else {
-				return newToken(keyword, word)
+				tok = newToken(keyword, word)
 			}
+			tok.Position = pos
+			tok.Doc = doc
+			return tok
 		} else if isDigit(l.ch) {
-			return newToken(Token.INT, getNumber(l))
+			tok = l.readNumber()
+			tok.Position = pos
+			tok.Doc = doc
+			return tok
 		} else {
 			tok = newToken(Token.ILLEGAL, "ILLEGAL")
 		}
@@ -111,9 +199,15 @@ func (l *Lexer) NextToken() Token.Token {
 
 	l.readNextChar()
 
+	tok.Position = pos
+	tok.Doc = doc
 	return tok
 }
 
+func (l *Lexer) position() Token.Position {
+	return Token.Position{File: l.file, Line: l.line, Column: l.column, Offset: l.curPos}
+}
+
 func (l *Lexer) peekToken() byte {
 	if l.readPos == len(l.input) {
 		return 0
@@ -121,12 +215,171 @@ func (l *Lexer) peekToken() byte {
 	return l.input[l.readPos]
 }
 
-func getNumber(l *Lexer) string {
+// readNumber reads an integer literal, in decimal or with a 0x, 0b or 0o prefix, or a
+// decimal float literal with a fraction or an exponent. Underscores may separate
+// digits. A number runs on through any letters that follow it, so that a malformed
+// one such as `0b102` or `12ab` is read whole into an ILLEGAL token.
+func (l *Lexer) readNumber() Token.Token {
 	initialPosition := l.curPos
-	for isDigit(l.ch) {
+	prefixed := l.ch == '0' && strings.IndexByte("xXbBoO", l.peekToken()) >= 0
+	tokenType := Token.TokenType(Token.INT)
+
+	for {
+		if l.ch == '.' && !prefixed && tokenType == Token.INT && isDigit(l.peekToken()) {
+			tokenType = Token.FLOAT
+			l.readNextChar()
+			continue
+		}
+		if !isDigit(l.ch) && !isLetter(l.ch) {
+			break
+		}
+
+		exponent := !prefixed && (l.ch == 'e' || l.ch == 'E')
 		l.readNextChar()
+		if exponent {
+			tokenType = Token.FLOAT
+			if l.ch == '+' || l.ch == '-' {
+				l.readNextChar()
+			}
+		}
 	}
-	return l.input[initialPosition:l.curPos]
+
+	literal := l.input[initialPosition:l.curPos]
+	if !validNumber(literal, tokenType == Token.FLOAT) {
+		return newToken(Token.ILLEGAL, literal)
+	}
+	return newToken(tokenType, literal)
+}
+
+func validNumber(literal string, float bool) bool {
+	if float {
+		mantissa, exponent := literal, ""
+		if i := strings.IndexAny(literal, "eE"); i >= 0 {
+			mantissa, exponent = literal[:i], strings.TrimLeft(literal[i+1:], "+-")
+			if !validDigits(exponent, isDigit) {
+				return false
+			}
+		}
+		whole, fraction := mantissa, ""
+		if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+			whole, fraction = mantissa[:i], mantissa[i+1:]
+			if !validDigits(fraction, isDigit) {
+				return false
+			}
+		}
+		return validDigits(whole, isDigit)
+	}
+
+	if len(literal) > 2 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			return validDigits(strings.TrimPrefix(literal[2:], "_"), isHexDigit)
+		case 'b', 'B':
+			return validDigits(strings.TrimPrefix(literal[2:], "_"), isBinaryDigit)
+		case 'o', 'O':
+			return validDigits(strings.TrimPrefix(literal[2:], "_"), isOctalDigit)
+		}
+	}
+	return validDigits(literal, isDigit)
+}
+
+// validDigits reports whether digits is a non-empty run of digits with single
+// underscores between them.
+func validDigits(digits string, isDigit func(byte) bool) bool {
+	if digits == "" || digits[0] == '_' || digits[len(digits)-1] == '_' || strings.Contains(digits, "__") {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] != '_' && !isDigit(digits[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// readString reads a double-quoted string literal and decodes its escape sequences,
+// leaving the lexer on the closing quote. Unterminated strings and unknown escapes
+// produce an ILLEGAL token holding the raw source text.
+func (l *Lexer) readString() Token.Token {
+	initialPosition := l.curPos
+	out := strings.Builder{}
+	valid := true
+
+	for {
+		l.readNextChar()
+
+		switch l.ch {
+		case '"':
+			if !valid {
+				return newToken(Token.ILLEGAL, l.input[initialPosition:l.readPos])
+			}
+			return newToken(Token.STRING, out.String())
+		case 0:
+			return newToken(Token.ILLEGAL, l.input[initialPosition:l.curPos])
+		case '\\':
+			l.readNextChar()
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case '"':
+				out.WriteByte('"')
+			case '\\':
+				out.WriteByte('\\')
+			case 'u':
+				r, ok := l.readUnicodeEscape()
+				valid = valid && ok
+				out.WriteRune(r)
+			case 0:
+				return newToken(Token.ILLEGAL, l.input[initialPosition:l.curPos])
+			default:
+				valid = false
+			}
+		default:
+			out.WriteByte(l.ch)
+		}
+	}
+}
+
+// readUnicodeEscape reads the '{hex}' part of a \u{...} escape, leaving the lexer on the closing brace.
+func (l *Lexer) readUnicodeEscape() (rune, bool) {
+	if l.peekToken() != '{' {
+		return utf8.RuneError, false
+	}
+	l.readNextChar()
+
+	initialPosition := l.readPos
+	for isHexDigit(l.peekToken()) {
+		l.readNextChar()
+	}
+	digits := l.input[initialPosition:l.readPos]
+
+	if l.peekToken() != '}' {
+		return utf8.RuneError, false
+	}
+	l.readNextChar()
+
+	if len(digits) == 0 || len(digits) > 6 {
+		return utf8.RuneError, false
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(value)) {
+		return utf8.RuneError, false
+	}
+	return rune(value), true
+}
+
+func isHexDigit(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func isBinaryDigit(b byte) bool {
+	return b == '0' || b == '1'
+}
+
+func isOctalDigit(b byte) bool {
+	return b >= '0' && b <= '7'
 }
 
 func isDigit(b byte) bool {
@@ -150,6 +403,11 @@ func newToken(Type Token.TokenType, Literal string) Token.Token {
 }
 
 func (l *Lexer) readNextChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	if l.readPos >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -159,10 +417,40 @@ func (l *Lexer) readNextChar() {
 	l.readPos++
 }
 
+// skipWhiteSpaces skips whitespace, "//" line comments and "/* */" block comments,
+// keeping the text of "///" doc comments for the next token. A block comment that is
+// never closed is left for NextToken to report.
 func (l *Lexer) skipWhiteSpaces() {
-	for charIsWhiteSpace(l.ch) {
+	for {
+		switch {
+		case charIsWhiteSpace(l.ch):
+			l.readNextChar()
+		case l.ch == '/' && l.peekToken() == '/':
+			l.skipLineComment()
+		case l.ch == '/' && l.peekToken() == '*' && strings.Contains(l.input[l.readPos+1:], "*/"):
+			l.readNextChar()
+			l.readNextChar()
+			for !(l.ch == '*' && l.peekToken() == '/') {
+				l.readNextChar()
+			}
+			l.readNextChar()
+			l.readNextChar()
+		default:
+			return
+		}
+	}
+}
+
+func (l *Lexer) skipLineComment() {
+	initialPosition := l.curPos
+	for l.ch != '\n' && l.ch != 0 {
 		l.readNextChar()
 	}
+
+	comment := strings.TrimSuffix(l.input[initialPosition:l.curPos], "\r")
+	if strings.HasPrefix(comment, "///") {
+		l.doc += strings.TrimPrefix(strings.TrimPrefix(comment, "///"), " ") + "\n"
+	}
 }
 
 func charIsWhiteSpace(ch byte) bool {
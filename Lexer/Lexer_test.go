@@ -8,7 +8,7 @@ import (
 func TestNextToken(t *testing.T) {
 	var input = `
 		true false
-		=;{}(),+!*/
+		=;{}(),+!*/[]:
 		monkeySay myVar =   99
 		monkeySay plus = monkeyDo(x, y) {
 			return x + y
@@ -34,6 +34,9 @@ func TestNextToken(t *testing.T) {
 		{Token.BANG, "!"},
 		{Token.MULTIPLY, "*"},
 		{Token.DIVIDE, "/"},
+		{Token.LBRACKET, "["},
+		{Token.RBRACKET, "]"},
+		{Token.COLON, ":"},
 		{Token.LET, "monkeySay"},
 		{Token.IDENT, "myVar"},
 		{Token.ASSIGN, "="},
@@ -116,6 +119,193 @@ func TestNumberAndWordLexing(t *testing.T) {
 	}
 }
 
+func TestNumberLiteralLexing(t *testing.T) {
+	var tests = []struct {
+		input        string
+		tokenType    Token.TokenType
+		tokenLiteral string
+	}{
+		{"1.5", Token.FLOAT, "1.5"},
+		{"1e9", Token.FLOAT, "1e9"},
+		{"2.5E-3", Token.FLOAT, "2.5E-3"},
+		{"1_000.000_1", Token.FLOAT, "1_000.000_1"},
+		{"0xff", Token.INT, "0xff"},
+		{"0XFF_FF", Token.INT, "0XFF_FF"},
+		{"0b1010", Token.INT, "0b1010"},
+		{"0o777", Token.INT, "0o777"},
+		{"0x_1", Token.INT, "0x_1"},
+		{"1_000_000", Token.INT, "1_000_000"},
+		{"007", Token.INT, "007"},
+		{"0b102", Token.ILLEGAL, "0b102"},
+		{"0o8", Token.ILLEGAL, "0o8"},
+		{"0x", Token.ILLEGAL, "0x"},
+		{"12ab", Token.ILLEGAL, "12ab"},
+		{"1__0", Token.ILLEGAL, "1__0"},
+		{"10_", Token.ILLEGAL, "10_"},
+		{"1_.5", Token.ILLEGAL, "1_.5"},
+		{"1e", Token.ILLEGAL, "1e"},
+		{"1e+", Token.ILLEGAL, "1e+"},
+		{"0x1.5", Token.INT, "0x1"},
+		{"1.", Token.INT, "1"},
+		{"1.2.3", Token.FLOAT, "1.2"},
+	}
+
+	for i, tt := range tests {
+		token := New(tt.input).NextToken()
+
+		if tt.tokenType != token.Type || tt.tokenLiteral != token.Literal {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.tokenType, tt.tokenLiteral, token.Type, token.Literal)
+		}
+	}
+}
+
+func TestCommentLexing(t *testing.T) {
+	input := `a // line comment
+/* block
+   comment */ b /**/ / c /* a // b */ d
+// last`
+
+	expected := []struct {
+		tokenType    Token.TokenType
+		tokenLiteral string
+		line         int
+	}{
+		{Token.IDENT, "a", 1},
+		{Token.IDENT, "b", 3},
+		{Token.DIVIDE, "/", 3},
+		{Token.IDENT, "c", 3},
+		{Token.IDENT, "d", 3},
+		{Token.EOF, "EOF", 4},
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		token := l.NextToken()
+
+		if token.Type != tt.tokenType || token.Literal != tt.tokenLiteral || token.Position.Line != tt.line {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q on line %d, got=%q %q on line %d",
+				i, tt.tokenType, tt.tokenLiteral, tt.line, token.Type, token.Literal, token.Position.Line)
+		}
+	}
+
+	l = New("1 /* never closed")
+	l.NextToken()
+	if token := l.NextToken(); token.Type != Token.ILLEGAL || token.Literal != "/* never closed" || token.Position.Column != 3 {
+		t.Fatalf("expected an ILLEGAL token for the unterminated comment, got %q %q at %s", token.Type, token.Literal, token.Position)
+	}
+	if token := l.NextToken(); token.Type != Token.EOF {
+		t.Fatalf("expected EOF after the unterminated comment, got %q", token.Type)
+	}
+}
+
+func TestDocCommentLexing(t *testing.T) {
+	input := "/// Adds one.\r\n///   Indented\n// plain\nx\n/// Trailing\ny z"
+
+	expected := []struct {
+		tokenLiteral string
+		doc          string
+	}{
+		{"x", "Adds one.\n  Indented"},
+		{"y", "Trailing"},
+		{"z", ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		token := l.NextToken()
+
+		if token.Literal != tt.tokenLiteral || token.Doc != tt.doc {
+			t.Fatalf("tests[%d] - token wrong. expected=%q with doc %q, got=%q with doc %q", i, tt.tokenLiteral, tt.doc, token.Literal, token.Doc)
+		}
+	}
+}
+
+func TestLoopKeywordLexing(t *testing.T) {
+	input := "while for break continue forever"
+
+	expected := []Token.TokenType{Token.WHILE, Token.FOR, Token.BREAK, Token.CONTINUE, Token.IDENT, Token.EOF}
+
+	l := New(input)
+
+	for i, tokenType := range expected {
+		token := l.NextToken()
+
+		if token.Type != tokenType {
+			t.Fatalf("tests[%d] - tokenType wrong. expected=%q, got=%q", i, tokenType, token.Type)
+		}
+	}
+}
+
+func TestAssignmentOperatorLexing(t *testing.T) {
+	input := "= += -= *= /= ++ -- + - * /"
+
+	expected := []Token.TokenType{
+		Token.ASSIGN, Token.PLUS_ASSIGN, Token.MINUS_ASSIGN, Token.MULTIPLY_ASSIGN, Token.DIVIDE_ASSIGN,
+		Token.INCR, Token.DECR, Token.PLUS, Token.MINUS, Token.MULTIPLY, Token.DIVIDE, Token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tokenType := range expected {
+		token := l.NextToken()
+
+		if token.Type != tokenType {
+			t.Fatalf("tests[%d] - tokenType wrong. expected=%q, got=%q", i, tokenType, token.Type)
+		}
+	}
+}
+
+func TestLogicalOperatorLexing(t *testing.T) {
+	input := "a && b || c & d | e"
+
+	expected := []struct {
+		tokenType    Token.TokenType
+		tokenLiteral string
+	}{
+		{Token.IDENT, "a"},
+		{Token.AND, "&&"},
+		{Token.IDENT, "b"},
+		{Token.OR, "||"},
+		{Token.IDENT, "c"},
+		{Token.ILLEGAL, "&"},
+		{Token.IDENT, "d"},
+		{Token.ILLEGAL, "|"},
+		{Token.IDENT, "e"},
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		token := l.NextToken()
+
+		if token.Type != tt.tokenType || token.Literal != tt.tokenLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.tokenType, tt.tokenLiteral, token.Type, token.Literal)
+		}
+	}
+}
+
+func TestMatchLexing(t *testing.T) {
+	input := "match (x) { 1 => a, _ => b } matches = =="
+
+	expected := []Token.TokenType{
+		Token.MATCH, Token.LBRACE, Token.IDENT, Token.RBRACE, Token.LPAREN,
+		Token.INT, Token.ARROW, Token.IDENT, Token.COMMA, Token.IDENT, Token.ARROW, Token.IDENT, Token.RPAREN,
+		Token.IDENT, Token.ASSIGN, Token.EQ, Token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tokenType := range expected {
+		token := l.NextToken()
+
+		if token.Type != tokenType {
+			t.Fatalf("tests[%d] - tokenType wrong. expected=%q, got=%q", i, tokenType, token.Type)
+		}
+	}
+}
+
 func TestPeekingTokens(t *testing.T) {
 	var input = `
 		== >= <= !=
@@ -144,3 +334,104 @@ func TestPeekingTokens(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	var input = "monkeySay x = 10;\n  x >= 2\n"
+
+	var tests = []struct {
+		tokenLiteral string
+		line         int
+		column       int
+		offset       int
+	}{
+		{"monkeySay", 1, 1, 0},
+		{"x", 1, 11, 10},
+		{"=", 1, 13, 12},
+		{"10", 1, 15, 14},
+		{";", 1, 17, 16},
+		{"x", 2, 3, 20},
+		{">=", 2, 5, 22},
+		{"2", 2, 8, 25},
+		{"EOF", 3, 1, 27},
+	}
+
+	l := NewWithFileName("test.chimp", input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+
+		if tt.tokenLiteral != token.Literal {
+			t.Fatalf("tests[%d] - tokenLiteral wrong. expected=%q, got=%q", i, tt.tokenLiteral, token.Literal)
+		}
+
+		pos := token.Position
+		if pos.Line != tt.line || pos.Column != tt.column || pos.Offset != tt.offset {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d (offset %d), got=%d:%d (offset %d)",
+				i, tt.line, tt.column, tt.offset, pos.Line, pos.Column, pos.Offset)
+		}
+
+		if pos.File != "test.chimp" {
+			t.Fatalf("tests[%d] - file wrong. expected=%q, got=%q", i, "test.chimp", pos.File)
+		}
+	}
+}
+
+func TestShebangLexing(t *testing.T) {
+	l := NewWithFileName("test.chimp", "#!/usr/bin/env chimp\nputs # 1")
+
+	tests := []struct {
+		tokenType Token.TokenType
+		line      int
+	}{
+		{Token.IDENT, 2},
+		{Token.ILLEGAL, 2},
+		{Token.INT, 2},
+		{Token.EOF, 2},
+	}
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.tokenType || token.Position.Line != tt.line {
+			t.Fatalf("tests[%d] - expected %q on line %d, got %q on line %d", i, tt.tokenType, tt.line, token.Type, token.Position.Line)
+		}
+	}
+}
+
+func TestStringLexing(t *testing.T) {
+	var input = `
+		"hello world"
+		"tab\there" "line\nbreak" "say \"hi\"" "back\\slash"
+		"smile \u{1F600}" ""
+		"bad \q escape" "unterminated
+`
+
+	var tests = []struct {
+		tokenType    Token.TokenType
+		tokenLiteral string
+	}{
+		{Token.STRING, "hello world"},
+		{Token.STRING, "tab\there"},
+		{Token.STRING, "line\nbreak"},
+		{Token.STRING, `say "hi"`},
+		{Token.STRING, `back\slash`},
+		{Token.STRING, "smile \U0001F600"},
+		{Token.STRING, ""},
+		{Token.ILLEGAL, `"bad \q escape"`},
+		{Token.ILLEGAL, "\"unterminated\n"},
+		{Token.EOF, "EOF"},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+
+		if tt.tokenType != token.Type {
+			t.Fatalf("tests[%d] - tokenType wrong. expected=%q, got=%q", i, tt.tokenType, token.Type)
+		}
+
+		if tt.tokenLiteral != token.Literal {
+			t.Fatalf("tests[%d] - tokenLiteral wrong. expected=%q, got=%q", i, tt.tokenLiteral, token.Literal)
+		}
+	}
+}
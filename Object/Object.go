@@ -4,14 +4,33 @@ import (
 	"Chimp/Ast"
 	"bytes"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type ObjectType string
 
 const (
 	INTEGER_OBJ  = "INTEGER"
+	FLOAT_OBJ    = "FLOAT"
 	BOOL_OBJ     = "BOOL"
+	STRING_OBJ   = "STRING"
+	ARRAY_OBJ    = "ARRAY"
+	MAP_OBJ      = "MAP"
 	FUNCTION_OBJ = "FUNCTION"
+	BUILTIN_OBJ  = "BUILTIN"
+	RETURN_OBJ   = "RETURN"
+	NULL_OBJ     = "NULL"
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
 )
 
 type Object interface {
@@ -19,9 +38,23 @@ type Object interface {
 	Inspect() string
 }
 
+// Hashable is implemented by objects that can be used as map keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
 type Environment struct {
-	outer *Environment
-	store map[string]Object
+	outer    *Environment
+	store    map[string]Object
+	output   io.Writer
+	args     []string
+	builtins map[string]Builtin
 }
 
 func NewEnvironment(outer *Environment) *Environment {
@@ -47,12 +80,147 @@ func (e Environment) Get(key string) (Object, bool) {
 	return object, ok
 }
 
+// Names returns, sorted, every name bound in this scope or an enclosing one.
+func (e Environment) Names() []string {
+	seen := map[string]bool{}
+	for scope := &e; scope != nil; scope = scope.outer {
+		for name := range scope.store {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Assign rebinds key in the scope that declared it, reporting false when no
+// enclosing scope declares key.
+func (e Environment) Assign(key string, obj Object) bool {
+	if _, ok := e.store[key]; ok {
+		e.store[key] = obj
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(key, obj)
+	}
+	return false
+}
+
+// SetOutput sets where builtins such as puts write. Scopes without an output of
+// their own use the one of their outer scope, and the outermost defaults to os.Stdout.
+func (e *Environment) SetOutput(w io.Writer) {
+	e.output = w
+}
+
+func (e Environment) Output() io.Writer {
+	if e.output != nil {
+		return e.output
+	}
+	if e.outer != nil {
+		return e.outer.Output()
+	}
+	return os.Stdout
+}
+
+// SetArgs sets the command-line arguments that the args builtin returns. Like the
+// output, scopes without arguments of their own use those of their outer scope.
+func (e *Environment) SetArgs(args []string) {
+	e.args = args
+}
+
+func (e Environment) Args() []string {
+	if e.args != nil {
+		return e.args
+	}
+	if e.outer != nil {
+		return e.outer.Args()
+	}
+	return nil
+}
+
+// SetBuiltin makes fn callable under name from this scope and the scopes it encloses,
+// without registering it for every environment as Evaluator.RegisterBuiltin does.
+func (e *Environment) SetBuiltin(name string, fn BuiltinFunction) {
+	if e.builtins == nil {
+		e.builtins = map[string]Builtin{}
+	}
+	e.builtins[name] = Builtin{Name: name, Fn: fn}
+}
+
+// Builtin returns the builtin set under name in this scope or an enclosing one.
+func (e Environment) Builtin(name string) (Builtin, bool) {
+	if builtin, ok := e.builtins[name]; ok {
+		return builtin, true
+	}
+	if e.outer != nil {
+		return e.outer.Builtin(name)
+	}
+	return Builtin{}, false
+}
+
 type Integer struct {
 	Value int64
 }
 
 func (i Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+func (i Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// BigInteger is an integer beyond the range of int64, which arithmetic on Integer
+// values promotes its result to instead of overflowing. It is an INTEGER like
+// Integer, and NewInteger keeps the two from ever holding the same value.
+type BigInteger struct {
+	Value *big.Int
+}
+
+// bigIntegerKey keeps the hash keys of big integers apart from those of Integer.
+const bigIntegerKey ObjectType = "BIG_INTEGER"
+
+func (b BigInteger) Type() ObjectType { return INTEGER_OBJ }
+func (b BigInteger) Inspect() string  { return b.Value.String() }
+func (b BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(b.Value.String()))
+	return HashKey{Type: bigIntegerKey, Value: h.Sum64()}
+}
+
+// NewInteger returns value as an Integer when it fits in an int64 and as a
+// BigInteger otherwise.
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return Integer{Value: value.Int64()}
+	}
+	return BigInteger{Value: value}
+}
+
+// Float is a 64-bit floating point number. Floats are not hashable, so that 1 and
+// 1.0, which are equal, cannot end up as different keys of a map.
+type Float struct {
+	Value float64
+}
+
+func (f Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect prints the shortest digits that read back as the same float, switching to
+// an exponent for very large and very small magnitudes, with a trailing ".0" on
+// whole numbers to tell them apart from integers.
+func (f Float) Inspect() string {
+	format := byte('f')
+	if magnitude := math.Abs(f.Value); magnitude != 0 && !(magnitude >= 1e-4 && magnitude < 1e21) {
+		format = 'g'
+	}
+	formatted := strconv.FormatFloat(f.Value, format, -1, 64)
+	if !strings.ContainsAny(formatted, ".eIN") {
+		formatted += ".0"
+	}
+	return formatted
+}
 
 type Boolean struct {
 	Value bool
@@ -60,6 +228,90 @@ type Boolean struct {
 
 func (b Boolean) Type() ObjectType { return BOOL_OBJ }
 func (b Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+func (b Boolean) HashKey() HashKey {
+	if b.Value {
+		return HashKey{Type: b.Type(), Value: 1}
+	}
+	return HashKey{Type: b.Type(), Value: 0}
+}
+
+type String struct {
+	Value string
+}
+
+func (s String) Type() ObjectType { return STRING_OBJ }
+func (s String) Inspect() string  { return s.Value }
+func (s String) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (a Array) Type() ObjectType { return ARRAY_OBJ }
+
+func (a Array) Inspect() string {
+	var elements = bytes.Buffer{}
+	for i, e := range a.Elements {
+		elements.WriteString(e.Inspect())
+		if i+1 != len(a.Elements) {
+			elements.WriteString(", ")
+		}
+	}
+
+	return fmt.Sprintf("[%s]", elements.String())
+}
+
+type MapPair struct {
+	Key   Object
+	Value Object
+}
+
+type Map struct {
+	Pairs map[HashKey]MapPair
+}
+
+func (m Map) Type() ObjectType { return MAP_OBJ }
+
+// Inspect lists the pairs sorted by key so that the output is stable.
+func (m Map) Inspect() string {
+	var pairs []string
+	for _, pair := range m.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+	sort.Strings(pairs)
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
+// Null is the value of constructs that produce nothing, such as an empty block.
+type Null struct{}
+
+func (n Null) Type() ObjectType { return NULL_OBJ }
+func (n Null) Inspect() string  { return "null" }
+
+// ReturnValue wraps the value of a return statement while it unwinds
+// to the enclosing function call or the end of the programme.
+type ReturnValue struct {
+	Value Object
+}
+
+func (r ReturnValue) Type() ObjectType { return RETURN_OBJ }
+func (r ReturnValue) Inspect() string  { return r.Value.Inspect() }
+
+// Break and Continue unwind a loop body to the innermost enclosing loop.
+type Break struct{}
+
+func (b Break) Type() ObjectType { return BREAK_OBJ }
+func (b Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c Continue) Inspect() string  { return "continue" }
 
 type Function struct {
 	Parameters []string
@@ -80,3 +332,15 @@ func (f Function) Inspect() string {
 
 	return fmt.Sprintf("(%v) %s", params.String(), f.Body.ToString())
 }
+
+// BuiltinFunction is a function implemented in Go, called with the environment of
+// the call site. Errors it returns are reported at the position of the call.
+type BuiltinFunction func(env *Environment, args ...Object) (Object, error)
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b Builtin) Inspect() string  { return fmt.Sprintf("builtin %s", b.Name) }
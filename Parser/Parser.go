@@ -5,7 +5,9 @@ import (
 	"Chimp/Lexer"
 	"Chimp/Token"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 type Parser struct {
@@ -16,6 +18,12 @@ type Parser struct {
 	infixRegistry  map[Token.TokenType]infixFunc
 	prefixRegistry map[Token.TokenType]prefixFunc
 	precedence     map[string]int
+	// loopDepth counts the loops enclosing the current statement within the current
+	// function, to reject a break or continue with no loop to leave.
+	loopDepth int
+	// braces counts the '{' up to the current token that are not yet closed, so that
+	// error recovery can tell which block a '}' closes.
+	braces int
 }
 
 type infixFunc = func(left Ast.Expression) Ast.Expression
@@ -28,6 +36,7 @@ func New(l Lexer.Lexer) *Parser {
 	p.advanceTokens()
 	p.infixRegistry = make(map[Token.TokenType]infixFunc)
 	p.infixRegistry[Token.LBRACE] = p.parseCallExpression
+	p.infixRegistry[Token.LBRACKET] = p.parseIndexExpression
 	p.infixRegistry[Token.GT] = p.parseInfixExpression
 	p.infixRegistry[Token.GTE] = p.parseInfixExpression
 	p.infixRegistry[Token.LT] = p.parseInfixExpression
@@ -38,18 +47,34 @@ func New(l Lexer.Lexer) *Parser {
 	p.infixRegistry[Token.MINUS] = p.parseInfixExpression
 	p.infixRegistry[Token.MULTIPLY] = p.parseInfixExpression
 	p.infixRegistry[Token.DIVIDE] = p.parseInfixExpression
+	p.infixRegistry[Token.AND] = p.parseInfixExpression
+	p.infixRegistry[Token.OR] = p.parseInfixExpression
+	p.infixRegistry[Token.ASSIGN] = p.parseAssignExpression
+	p.infixRegistry[Token.PLUS_ASSIGN] = p.parseAssignExpression
+	p.infixRegistry[Token.MINUS_ASSIGN] = p.parseAssignExpression
+	p.infixRegistry[Token.MULTIPLY_ASSIGN] = p.parseAssignExpression
+	p.infixRegistry[Token.DIVIDE_ASSIGN] = p.parseAssignExpression
+	p.infixRegistry[Token.INCR] = p.parsePostfixUpdateExpression
+	p.infixRegistry[Token.DECR] = p.parsePostfixUpdateExpression
 
 	p.prefixRegistry = make(map[Token.TokenType]prefixFunc)
 	p.prefixRegistry[Token.FUNCTION] = p.parseFunctionExpression
+	p.prefixRegistry[Token.IF] = p.parseIfExpression
+	p.prefixRegistry[Token.MATCH] = p.parseMatchExpression
 	p.prefixRegistry[Token.IDENT] = p.parseIdentExpression
 	p.prefixRegistry[Token.BANG] = p.parsePrefixExpression
 	p.prefixRegistry[Token.MINUS] = p.parsePrefixExpression
-	p.prefixRegistry[Token.INCR] = p.parsePrefixExpression
-	p.prefixRegistry[Token.DECR] = p.parsePrefixExpression
+	p.prefixRegistry[Token.INCR] = p.parsePrefixUpdateExpression
+	p.prefixRegistry[Token.DECR] = p.parsePrefixUpdateExpression
 	p.prefixRegistry[Token.LBRACE] = p.parseBracePrefixExpression
+	p.prefixRegistry[Token.LBRACKET] = p.parseArrayExpression
+	p.prefixRegistry[Token.LPAREN] = p.parseMapExpression
 
 	p.precedence = make(map[string]int)
 	p.precedence["("] = CALL
+	p.precedence["["] = CALL
+	p.precedence["++"] = CALL
+	p.precedence["--"] = CALL
 	p.precedence["/"] = MULTI
 	p.precedence["*"] = MULTI
 	p.precedence["+"] = SUM
@@ -60,6 +85,13 @@ func New(l Lexer.Lexer) *Parser {
 	p.precedence["<="] = EQUALS
 	p.precedence["=="] = EQUALS
 	p.precedence["!="] = EQUALS
+	p.precedence["&&"] = AND
+	p.precedence["||"] = OR
+	p.precedence["="] = ASSIGN
+	p.precedence["+="] = ASSIGN
+	p.precedence["-="] = ASSIGN
+	p.precedence["*="] = ASSIGN
+	p.precedence["/="] = ASSIGN
 	p.precedence["LOWEST"] = LOWEST
 
 	return &p
@@ -67,9 +99,13 @@ func New(l Lexer.Lexer) *Parser {
 
 const (
 	LOWEST = iota
+	ASSIGN
+	OR
+	AND
 	EQUALS
 	SUM
 	MULTI
+	PREFIX
 	CALL
 )
 
@@ -77,15 +113,26 @@ func (p *Parser) GetErrors() []string {
 	return p.errors
 }
 
+func (p *Parser) addError(token Token.Token, format string, a ...interface{}) {
+	p.errors = append(p.errors, fmt.Sprintf("%s: %s", token.Position, fmt.Sprintf(format, a...)))
+}
+
 func (p *Parser) ParseProgramme() Ast.Programme {
 	programme := Ast.Programme{}
 	programme.Statements = []Ast.Statement{}
 
 	for p.getCurrentToken().Type != Token.EOF {
+		start, braces := p.getCurrentToken(), p.braces
+		errorCount := len(p.errors)
+
 		if statement := p.parseStatement(); statement != nil {
 			programme.Statements = append(programme.Statements, statement)
 		}
 
+		if len(p.errors) > errorCount && p.synchronise(start, braces) {
+			continue
+		}
+
 		p.advanceTokens()
 	}
 
@@ -97,11 +144,24 @@ func (p *Parser) parseStatement() Ast.Statement {
 	case Token.LET:
 		return p.parseLetStatement()
 	case Token.LPAREN:
-		return p.parseBlockStatement()
+		if p.startsMapLiteral() {
+			return p.parseExpressionStatement()
+		}
+		block := p.parseBlockStatement()
+		if p.getPeekToken().Type == Token.SEMICOLON {
+			p.advanceTokens()
+		}
+		return block
 	case Token.RETURN:
 		return p.parseReturnStatement()
 	case Token.IF:
 		return p.parseIfStatement()
+	case Token.WHILE:
+		return p.parseWhileStatement()
+	case Token.FOR:
+		return p.parseForStatement()
+	case Token.BREAK, Token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -117,8 +177,7 @@ func (p *Parser) parseExpression(contextPrecedence int) Ast.Expression {
 		leftExp = p.parseLiteral()
 	}
 
-	operatorPres := p.getPeekPrecedence()
-	for operatorPres >= contextPrecedence {
+	for p.getPeekPrecedence() > contextPrecedence {
 		infix := p.infixRegistry[p.getPeekToken().Type]
 		if infix == nil {
 			return leftExp
@@ -130,18 +189,18 @@ func (p *Parser) parseExpression(contextPrecedence int) Ast.Expression {
 	return leftExp
 }
 
-func (p *Parser) parseLetStatement() *Ast.LetStatement {
+func (p *Parser) parseLetStatement() Ast.Statement {
 	letToken := p.getCurrentToken()
 
 	if p.advanceTokens(); p.getCurrentToken().Type != Token.IDENT {
-		p.errors = append(p.errors, fmt.Sprintf("expected IDENT, but received '%s'", p.getCurrentToken().Literal))
+		p.addError(p.getCurrentToken(), "expected IDENT, but received '%s'", p.getCurrentToken().Literal)
 		return nil
 	}
 
 	identityExpression := *p.parseIdentExpression().(*Ast.IdentityExpression)
 
 	if p.getPeekToken().Type != Token.ASSIGN {
-		p.errors = append(p.errors, fmt.Sprintf("expected '=', but received '%s'", p.getCurrentToken().Literal))
+		p.addError(p.getPeekToken(), "expected '=', but received '%s'", p.getPeekToken().Literal)
 		return nil
 	}
 	p.advanceTokens()
@@ -152,6 +211,7 @@ func (p *Parser) parseLetStatement() *Ast.LetStatement {
 		Token: letToken,
 		Name:  identityExpression,
 		Value: valueExpression,
+		Doc:   letToken.Doc,
 	}
 
 	if p.getPeekToken().Type == Token.SEMICOLON {
@@ -180,7 +240,7 @@ func (p *Parser) parseReturnStatement() *Ast.ReturnStatement {
 
 func (p *Parser) parseExpressionStatement() Ast.ExpressionStatement {
 	statement := Ast.ExpressionStatement{
-		Token: Token.Token{},
+		Token: p.getCurrentToken(),
 		Value: p.parseExpression(LOWEST),
 	}
 
@@ -197,41 +257,89 @@ func (p *Parser) parseBlockStatement() Ast.BlockStatement {
 
 	var statements []Ast.Statement
 	for p.getCurrentToken().Type != Token.RPAREN && p.getCurrentToken().Type != Token.EOF {
-		statements = append(statements, p.parseStatement())
+		start, braces := p.getCurrentToken(), p.braces
+		errorCount := len(p.errors)
+
+		if statement := p.parseStatement(); statement != nil {
+			statements = append(statements, statement)
+		}
+
+		if len(p.errors) > errorCount && p.synchronise(start, braces) {
+			continue
+		}
+
 		p.advanceTokens()
 	}
 
+	if p.getCurrentToken().Type == Token.EOF {
+		p.addError(token, "block is never closed, expected '}'")
+	}
+
 	return Ast.BlockStatement{
 		Token:      token,
 		Statements: statements,
 	}
 }
 
-func (p *Parser) parseIfStatement() Ast.IfStatement {
+func (p *Parser) parseIfStatement() Ast.Statement {
+	expression := p.parseIfExpression()
+	if expression == nil {
+		return nil
+	}
+
+	if p.getPeekToken().Type == Token.SEMICOLON {
+		p.advanceTokens()
+	}
+
+	return expression.(Ast.IfStatement)
+}
+
+// parseIfExpression parses an if where a value is expected, as in
+// `monkeySay x = if (a > b) { a } else { b };`, leaving a trailing semicolon to the
+// enclosing statement.
+func (p *Parser) parseIfExpression() Ast.Expression {
 	token := p.getCurrentToken()
 
 	p.advanceTokens()
 
-	if p.getCurrentToken().Type != Token.LBRACE { panic("if statement cond needs brace")}
+	if !p.expectCurrent(Token.LBRACE) {
+		return nil
+	}
+
+	errorCount := len(p.errors)
 
 	condition := p.parseExpression(LOWEST)
 
-	if p.getCurrentToken().Type != Token.RBRACE { panic("if statement cond needs brace")}
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.RBRACE) {
+		return nil
+	}
 
 	p.advanceTokens()
 
+	if !p.expectCurrent(Token.LPAREN) {
+		return nil
+	}
+
 	thenStatement := p.parseBlockStatement()
 
-	var elseStatement Ast.BlockStatement
+	var elseStatement Ast.Statement = Ast.BlockStatement{}
 
 	if p.getPeekToken().Type == Token.ELSE {
 		p.advanceTokens()
 		p.advanceTokens()
-		elseStatement = p.parseBlockStatement()
-	}
 
-	if p.getPeekToken().Type == Token.SEMICOLON {
-		p.advanceTokens()
+		if p.getCurrentToken().Type == Token.IF {
+			elseIf := p.parseIfExpression()
+			if elseIf == nil {
+				return nil
+			}
+			elseStatement = elseIf.(Ast.IfStatement)
+		} else {
+			if !p.expectCurrent(Token.LPAREN) {
+				return nil
+			}
+			elseStatement = p.parseBlockStatement()
+		}
 	}
 
 	return Ast.IfStatement{
@@ -242,6 +350,228 @@ func (p *Parser) parseIfStatement() Ast.IfStatement {
 	}
 }
 
+// parseMatchExpression parses `match (value) { pattern => body, ... }`, where a body
+// starting with '{' is a block unless it opens a map literal.
+func (p *Parser) parseMatchExpression() Ast.Expression {
+	token := p.getCurrentToken()
+
+	p.advanceTokens()
+
+	if !p.expectCurrent(Token.LBRACE) {
+		return nil
+	}
+
+	errorCount := len(p.errors)
+
+	value := p.parseExpression(LOWEST)
+
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.RBRACE) {
+		return nil
+	}
+
+	p.advanceTokens()
+
+	if !p.expectCurrent(Token.LPAREN) {
+		return nil
+	}
+
+	var arms []Ast.MatchArm
+	for p.getPeekToken().Type != Token.RPAREN {
+		p.advanceTokens()
+
+		pattern := p.parsePattern()
+		if pattern == nil {
+			return nil
+		}
+
+		p.advanceTokens()
+		if !p.expectCurrent(Token.ARROW) {
+			return nil
+		}
+
+		p.advanceTokens()
+		body := p.parseArmBody()
+		if len(p.errors) > errorCount {
+			return nil
+		}
+		arms = append(arms, Ast.MatchArm{Pattern: pattern, Body: body})
+
+		if p.getPeekToken().Type != Token.RPAREN {
+			p.advanceTokens()
+			if !p.expectCurrent(Token.COMMA) {
+				return nil
+			}
+		}
+	}
+
+	p.advanceTokens()
+
+	if len(arms) == 0 {
+		p.addError(token, "match needs at least one arm")
+		return nil
+	}
+
+	return &Ast.MatchExpression{
+		Token: token,
+		Value: value,
+		Arms:  arms,
+	}
+}
+
+// parsePattern parses the pattern of a match arm: a number, which may be negated,
+// a string or boolean literal, or an identifier, "_" being the wildcard.
+func (p *Parser) parsePattern() Ast.Expression {
+	switch p.getCurrentToken().Type {
+	case Token.IDENT:
+		return p.parseIdentExpression()
+	case Token.INT, Token.FLOAT, Token.STRING, Token.TRUE, Token.FALSE:
+		return p.parseLiteral()
+	case Token.MINUS:
+		if p.getPeekToken().Type == Token.INT || p.getPeekToken().Type == Token.FLOAT {
+			return p.parsePrefixExpression()
+		}
+	}
+	p.addError(p.getCurrentToken(), "invalid pattern '%s'", p.getCurrentToken().Literal)
+	return nil
+}
+
+func (p *Parser) parseArmBody() Ast.Statement {
+	if p.getCurrentToken().Type == Token.LPAREN && !p.startsMapLiteral() {
+		return p.parseBlockStatement()
+	}
+	return Ast.ExpressionStatement{
+		Token: p.getCurrentToken(),
+		Value: p.parseExpression(LOWEST),
+	}
+}
+
+func (p *Parser) parseWhileStatement() Ast.Statement {
+	token := p.getCurrentToken()
+
+	p.advanceTokens()
+
+	if !p.expectCurrent(Token.LBRACE) {
+		return nil
+	}
+
+	errorCount := len(p.errors)
+
+	condition := p.parseExpression(LOWEST)
+
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.RBRACE) {
+		return nil
+	}
+
+	p.advanceTokens()
+
+	body, ok := p.parseLoopBody()
+	if !ok {
+		return nil
+	}
+
+	return &Ast.WhileStatement{
+		Token:     token,
+		Condition: condition,
+		Body:      body,
+	}
+}
+
+func (p *Parser) parseForStatement() Ast.Statement {
+	token := p.getCurrentToken()
+
+	p.advanceTokens()
+
+	if !p.expectCurrent(Token.LBRACE) {
+		return nil
+	}
+
+	p.advanceTokens()
+
+	errorCount := len(p.errors)
+	statement := &Ast.ForStatement{Token: token}
+
+	if p.getCurrentToken().Type != Token.SEMICOLON {
+		statement.Init = p.parseForClause()
+		if p.getCurrentToken().Type != Token.SEMICOLON {
+			p.advanceTokens()
+		}
+	}
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.SEMICOLON) {
+		return nil
+	}
+
+	if p.advanceTokens(); p.getCurrentToken().Type != Token.SEMICOLON {
+		statement.Condition = p.parseExpression(LOWEST)
+		p.advanceTokens()
+	}
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.SEMICOLON) {
+		return nil
+	}
+
+	if p.advanceTokens(); p.getCurrentToken().Type != Token.RBRACE {
+		statement.Post = p.parseForClause()
+		p.advanceTokens()
+	}
+	if len(p.errors) > errorCount || !p.expectCurrent(Token.RBRACE) {
+		return nil
+	}
+
+	p.advanceTokens()
+
+	body, ok := p.parseLoopBody()
+	if !ok {
+		return nil
+	}
+	statement.Body = body
+
+	return statement
+}
+
+// parseForClause parses the init or post clause of a for statement. Unlike
+// parseLetStatement, an expression clause leaves a following semicolon unconsumed.
+func (p *Parser) parseForClause() Ast.Statement {
+	token := p.getCurrentToken()
+
+	if token.Type == Token.LET {
+		return p.parseLetStatement()
+	}
+	return Ast.ExpressionStatement{Token: token, Value: p.parseExpression(LOWEST)}
+}
+
+func (p *Parser) parseLoopBody() (Ast.BlockStatement, bool) {
+	if !p.expectCurrent(Token.LPAREN) {
+		return Ast.BlockStatement{}, false
+	}
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	if p.getPeekToken().Type == Token.SEMICOLON {
+		p.advanceTokens()
+	}
+
+	return body, true
+}
+
+func (p *Parser) parseLoopControlStatement() Ast.Statement {
+	token := p.getCurrentToken()
+
+	if p.loopDepth == 0 {
+		p.addError(token, "'%s' is only allowed inside a loop", token.Literal)
+		return nil
+	}
+
+	if p.getPeekToken().Type == Token.SEMICOLON {
+		p.advanceTokens()
+	}
+
+	if token.Type == Token.BREAK {
+		return &Ast.BreakStatement{Token: token}
+	}
+	return &Ast.ContinueStatement{Token: token}
+}
+
 func (p *Parser) parseIdentExpression() Ast.Expression {
 	token := p.getCurrentToken()
 
@@ -255,6 +585,13 @@ func (p *Parser) parseLiteral() Ast.Expression {
 	switch p.getCurrentToken().Type {
 	case Token.INT:
 		return p.parseIntegerExpression()
+	case Token.FLOAT:
+		return p.parseFloatExpression()
+	case Token.STRING:
+		return &Ast.StringExpression{
+			Token: p.getCurrentToken(),
+			Value: p.getCurrentToken().Literal,
+		}
 	case Token.TRUE:
 		return &Ast.BoolExpression{
 			Token: p.getCurrentToken(),
@@ -266,17 +603,42 @@ func (p *Parser) parseLiteral() Ast.Expression {
 			Value: false,
 		}
 	}
-	p.errors = append(p.errors, fmt.Sprintf("cannot parse literal '%s'", p.getCurrentToken().Literal))
+	p.addError(p.getCurrentToken(), "cannot parse literal '%s'", p.getCurrentToken().Literal)
 	return nil
 }
 
+// parseIntegerExpression converts an INT literal, whose form the lexer has checked.
 func (p *Parser) parseIntegerExpression() *Ast.IntegerExpression {
-	i, err := strconv.Atoi(p.getCurrentToken().Literal)
+	digits, base := strings.ReplaceAll(p.getCurrentToken().Literal, "_", ""), 10
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X':
+			digits, base = digits[2:], 16
+		case 'b', 'B':
+			digits, base = digits[2:], 2
+		case 'o', 'O':
+			digits, base = digits[2:], 8
+		}
+	}
+
+	value, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		p.addError(p.getCurrentToken(), "Non number in INT value")
+		return nil
+	}
+	if value.IsInt64() {
+		return &Ast.IntegerExpression{Token: p.getCurrentToken(), Value: value.Int64()}
+	}
+	return &Ast.IntegerExpression{Token: p.getCurrentToken(), Big: value}
+}
+
+func (p *Parser) parseFloatExpression() Ast.Expression {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(p.getCurrentToken().Literal, "_", ""), 64)
 	if err != nil {
-		p.errors = append(p.errors, "Non number in INT value")
+		p.addError(p.getCurrentToken(), "float literal '%s' is out of range", p.getCurrentToken().Literal)
 		return nil
 	}
-	return &Ast.IntegerExpression{Token: p.getCurrentToken(), Value: int64(i)}
+	return &Ast.FloatExpression{Token: p.getCurrentToken(), Value: value}
 }
 
 func (p *Parser) parseFunctionExpression() Ast.Expression {
@@ -284,37 +646,64 @@ func (p *Parser) parseFunctionExpression() Ast.Expression {
 
 	p.advanceTokens()
 
-	parameters := p.parseParameters()
+	parameters, ok := p.parseParameters()
+	if !ok {
+		return nil
+	}
+
+	identityParams, ok := p.mapParamsToIdentityExpressions(parameters)
+	if !ok {
+		return nil
+	}
 
 	p.advanceTokens()
 
+	if !p.expectCurrent(Token.LPAREN) {
+		return nil
+	}
+
+	// a loop around the function literal cannot be left from inside its body
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	body := p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
 	functionExpression := Ast.FunctionExpression{
 		Token:      token,
-		Parameters: mapParamsToIdentityExpressions(parameters),
+		Parameters: identityParams,
 		Body:       body,
 	}
 	return &functionExpression
 }
 
-func mapParamsToIdentityExpressions(parameters []Ast.Expression) []Ast.IdentityExpression {
+func (p *Parser) mapParamsToIdentityExpressions(parameters []Ast.Expression) ([]Ast.IdentityExpression, bool) {
 	var identityParams []Ast.IdentityExpression
-	for _, p := range parameters {
-		identityParams = append(identityParams, *p.(*Ast.IdentityExpression))
+	for _, param := range parameters {
+		identity, ok := param.(*Ast.IdentityExpression)
+		if !ok {
+			p.addError(p.getCurrentToken(), "function parameters must be identifiers")
+			return nil, false
+		}
+		identityParams = append(identityParams, *identity)
 	}
-	return identityParams
+	return identityParams, true
 }
 
-func (p *Parser) parseParameters() []Ast.Expression {
-	if p.getCurrentToken().Type != Token.LBRACE {
-		panic("params should be in braces!")
+func (p *Parser) parseParameters() ([]Ast.Expression, bool) {
+	return p.parseExpressionList(Token.LBRACE, Token.RBRACE)
+}
+
+// parseExpressionList parses comma separated expressions between the start and end
+// tokens, leaving the parser on the end token.
+func (p *Parser) parseExpressionList(start Token.TokenType, end Token.TokenType) ([]Ast.Expression, bool) {
+	if !p.expectCurrent(start) {
+		return nil, false
 	}
 
 	p.advanceTokens()
 
-	if p.getCurrentToken().Type == Token.RBRACE {
-		return []Ast.Expression{}
+	if p.getCurrentToken().Type == end {
+		return []Ast.Expression{}, true
 	}
 
 	param := p.parseExpression(LOWEST)
@@ -328,17 +717,104 @@ func (p *Parser) parseParameters() []Ast.Expression {
 
 	p.advanceTokens()
 
-	if p.getCurrentToken().Type != Token.RBRACE {
-		panic(fmt.Sprintf("should end with rbrace! got: %v", p.getCurrentToken().Type))
+	if !p.expectCurrent(end) {
+		return nil, false
+	}
+	return expressions, true
+}
+
+func (p *Parser) parseArrayExpression() Ast.Expression {
+	token := p.getCurrentToken()
+	elements, ok := p.parseExpressionList(Token.LBRACKET, Token.RBRACKET)
+	if !ok {
+		return nil
+	}
+
+	return &Ast.ArrayExpression{
+		Token:    token,
+		Elements: elements,
+	}
+}
+
+// startsMapLiteral reports whether the '{' in statement position opens a map literal
+// rather than a block: whether it is empty, as an empty block would do nothing, or its
+// first expression is followed by a ':'. That expression is parsed ahead, and the
+// parser then put back as it was, errors included.
+func (p *Parser) startsMapLiteral() bool {
+	if p.getPeekToken().Type == Token.RPAREN {
+		return true
+	}
+
+	saved := *p
+	p.advanceTokens()
+	p.parseExpression(LOWEST)
+	isMap := p.getPeekToken().Type == Token.COLON
+	*p = saved
+	return isMap
+}
+
+func (p *Parser) parseMapExpression() Ast.Expression {
+	token := p.getCurrentToken()
+	pairs := []Ast.MapPair{}
+
+	for p.getPeekToken().Type != Token.RPAREN {
+		p.advanceTokens()
+		key := p.parseExpression(LOWEST)
+
+		p.advanceTokens()
+		if !p.expectCurrent(Token.COLON) {
+			return nil
+		}
+
+		p.advanceTokens()
+		value := p.parseExpression(LOWEST)
+
+		pairs = append(pairs, Ast.MapPair{Key: key, Value: value})
+
+		if p.getPeekToken().Type != Token.RPAREN {
+			p.advanceTokens()
+			if !p.expectCurrent(Token.COMMA) {
+				return nil
+			}
+		}
+	}
+
+	p.advanceTokens()
+
+	return &Ast.MapExpression{
+		Token: token,
+		Pairs: pairs,
+	}
+}
+
+func (p *Parser) parseIndexExpression(left Ast.Expression) Ast.Expression {
+	token := p.getCurrentToken()
+	p.advanceTokens()
+
+	index := p.parseExpression(LOWEST)
+
+	p.advanceTokens()
+
+	if !p.expectCurrent(Token.RBRACKET) {
+		return nil
+	}
+
+	return &Ast.IndexExpression{
+		Token:  token,
+		Target: left,
+		Index:  index,
 	}
-	return expressions
 }
 
 func (p *Parser) parseCallExpression(left Ast.Expression) Ast.Expression {
-	parameters := p.parseParameters()
+	token := p.getCurrentToken()
+	parameters, ok := p.parseParameters()
+	if !ok {
+		return nil
+	}
 
 	callExpression := Ast.CallExpression{
-		Token:      Token.Token{},
+		Token:      token,
 		Target:     left,
 		Parameters: parameters,
 	}
@@ -346,7 +822,8 @@ func (p *Parser) parseCallExpression(left Ast.Expression) Ast.Expression {
 }
 
 func (p *Parser) parseInfixExpression(left Ast.Expression) Ast.Expression {
-	operator := p.getCurrentToken().Literal
+	token := p.getCurrentToken()
+	operator := token.Literal
 	precedence := p.getCurrentPrecedence()
 
 	p.advanceTokens()
@@ -355,26 +832,76 @@ func (p *Parser) parseInfixExpression(left Ast.Expression) Ast.Expression {
 
 	// last token ends at right-expression
 	return &Ast.InfixExpression{
-		Token:           Token.Token{},
+		Token:           token,
 		Operator:        operator,
 		LeftExpression:  left,
 		RightExpression: right,
 	}
 }
 
+// parseAssignExpression parses the value with the lowest precedence, so assignments
+// group to the right and "x = y = 1" assigns 1 to both.
+func (p *Parser) parseAssignExpression(left Ast.Expression) Ast.Expression {
+	token := p.getCurrentToken()
+
+	// left is not stringified: after an earlier error it may be nil or incomplete
+	name, ok := left.(*Ast.IdentityExpression)
+	if !ok || name == nil {
+		p.addError(token, "cannot assign to this expression")
+		return nil
+	}
+
+	p.advanceTokens()
+
+	return &Ast.AssignExpression{
+		Token:    token,
+		Name:     *name,
+		Operator: token.Literal,
+		Value:    p.parseExpression(LOWEST),
+	}
+}
+
+func (p *Parser) parsePrefixUpdateExpression() Ast.Expression {
+	token := p.getCurrentToken()
+
+	if p.advanceTokens(); p.getCurrentToken().Type != Token.IDENT {
+		p.addError(p.getCurrentToken(), "expected IDENT, but received '%s'", p.getCurrentToken().Literal)
+		return nil
+	}
+
+	return &Ast.UpdateExpression{
+		Token:    token,
+		Name:     *p.parseIdentExpression().(*Ast.IdentityExpression),
+		Operator: token.Literal,
+		Prefix:   true,
+	}
+}
+
+func (p *Parser) parsePostfixUpdateExpression(left Ast.Expression) Ast.Expression {
+	token := p.getCurrentToken()
+
+	name, ok := left.(*Ast.IdentityExpression)
+	if !ok || name == nil {
+		p.addError(token, "cannot apply '%s' to this expression", token.Literal)
+		return nil
+	}
+
+	return &Ast.UpdateExpression{
+		Token:    token,
+		Name:     *name,
+		Operator: token.Literal,
+	}
+}
+
 func (p *Parser) parsePrefixExpression() Ast.Expression {
 	token := p.getCurrentToken()
 	p.advanceTokens()
 
 	return &Ast.PrefixExpression{
-		Token: Token.Token{
-			Type:    token.Type,
-			Literal: token.Literal,
-		},
+		Token:      token,
 		Operator:   token.Literal,
-		Expression: p.parseLiteral(),
+		Expression: p.parseExpression(PREFIX),
 	}
-	// last token handled pos by parseIntegerExpression
 }
 
 func (p *Parser) parseBracePrefixExpression() Ast.Expression {
@@ -384,14 +911,59 @@ func (p *Parser) parseBracePrefixExpression() Ast.Expression {
 
 	p.advanceTokens()
 
-	if p.getCurrentToken().Type != Token.RBRACE { panic("brace parsing went wrong!") }
+	if !p.expectCurrent(Token.RBRACE) {
+		return nil
+	}
 
 	return expression
 	//last token pos at right brace
 }
 
-func (p *Parser) ignoreUntilSemicolon() {
-	for p.getCurrentToken().Type != Token.SEMICOLON && p.getCurrentToken().Type != Token.EOF {
+// expectCurrent records an error unless the current token is of the given type.
+func (p *Parser) expectCurrent(tokenType Token.TokenType) bool {
+	if p.getCurrentToken().Type == tokenType {
+		return true
+	}
+	p.addError(p.getCurrentToken(), "expected '%s', but received '%s'", tokenType, p.getCurrentToken().Literal)
+	return false
+}
+
+// synchronise skips the rest of a statement that failed to parse, stopping on its
+// closing semicolon or just before the next statement or the end of the enclosing
+// block, so that parsing can carry on and report every error in one pass. The
+// statement started on start with braces open.
+//
+// It reports whether the statement failed on a token beyond it, which starts the next
+// statement or is the '}' closing the enclosing block, and is left to be parsed.
+func (p *Parser) synchronise(start Token.Token, braces int) bool {
+	if p.getCurrentToken().Position != start.Position {
+		switch p.getCurrentToken().Type {
+		case Token.RPAREN:
+			if p.braces < braces {
+				return true
+			}
+		case Token.LET, Token.RETURN, Token.WHILE, Token.FOR, Token.BREAK, Token.CONTINUE:
+			return true
+		}
+	}
+
+	for {
+		// depth counts the braces the statement has opened and not closed
+		depth := p.braces - braces
+		switch {
+		case p.getCurrentToken().Type == Token.EOF:
+			return false
+		case depth > 0:
+		case p.getCurrentToken().Type == Token.SEMICOLON:
+			return false
+		default:
+			switch p.getPeekToken().Type {
+			case Token.LET, Token.RETURN, Token.IF, Token.WHILE, Token.FOR, Token.BREAK, Token.CONTINUE,
+				Token.MATCH, Token.RPAREN, Token.EOF:
+				return false
+			}
+		}
+
 		p.advanceTokens()
 	}
 }
@@ -399,6 +971,13 @@ func (p *Parser) ignoreUntilSemicolon() {
 func (p *Parser) advanceTokens() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case Token.LPAREN:
+		p.braces++
+	case Token.RPAREN:
+		p.braces--
+	}
 }
 
 func (p *Parser) getCurrentToken() Token.Token {
@@ -4,7 +4,8 @@ import (
 	"Chimp/Ast"
 	"Chimp/Lexer"
 	"Chimp/Token"
-	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +117,99 @@ func TestParseIfStatements(t *testing.T) {
 	}
 }
 
+func TestParseIfExpressions(t *testing.T) {
+	input := `
+		monkeySay x = if (a > b) { a } else { b };
+		monkeySay y = 1 + if (c) { 2 } * 3;
+		f(if (c) { 1 } else { 2 })
+		if (a < b) { 1 } else if (a == b) { 2 } else { 3 }
+		if (a) { 1 } else if (b) { 2 }
+		monkeySay m = match (x) { 1 => one, -2 => -2, "s" => s, true => t, n => n + 1, _ => { f(); 0 } };
+		match (x) { _ => { "a": 1 } }
+	`
+	output := []string{
+		"x = if (a > b) { a } else { b }",
+		"y = (1 + (if c { 2 } * 3))",
+		"funf(if c { 1 } else { 2 })",
+		"if (a < b) { 1 } else if (a == b) { 2 } else { 3 }",
+		"if a { 1 } else if b { 2 }",
+		"m = match x { 1 => one, (-2) => (-2), \"s\" => s, true => t, n => (n + 1), _ => { funf()0 } }",
+		"match x { _ => {\"a\": 1} }",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != len(output) {
+		t.Fatalf("Expected %d statements, got %d", len(output), len(programme.Statements))
+	}
+
+	for i, statement := range programme.Statements {
+		if statement.ToString() != output[i] {
+			t.Fatalf("Statement %d: Expected:\n%s \ngot: \n%s", i, output[i], statement.ToString())
+		}
+	}
+}
+
+func TestParseLoopStatements(t *testing.T) {
+	input := `
+		while (i < 10) { monkeySay i = i + 1; }
+		for (monkeySay i = 0; i < 10; monkeySay i = i + 1) { if (i == 5) { break } continue; };
+		for (;;) { break }
+		for (f(); x; g()) { }
+		while (true) { monkeyDo() { 1 }; break }
+	`
+	output := []string{
+		"while (i < 10) { i = (i + 1) }",
+		"for (i = 0; (i < 10); i = (i + 1)) { if (i == 5) { break }continue }",
+		"for (;;) { break }",
+		"for (funf(); x; fung()) ",
+		"while true { () { 1 }break }",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != len(output) {
+		t.Fatalf("Expected %d statements, got %d", len(output), len(programme.Statements))
+	}
+
+	for i, statement := range programme.Statements {
+		if statement.ToString() != output[i] {
+			t.Fatalf("Statement %d: Expected:\n%s \ngot: \n%s", i, output[i], statement.ToString())
+		}
+	}
+}
+
+func TestParseLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break", "<input>:1:1: 'break' is only allowed inside a loop"},
+		{"if (true) { continue }", "<input>:1:13: 'continue' is only allowed inside a loop"},
+		{"while (true) { monkeyDo() { break } }", "<input>:1:29: 'break' is only allowed inside a loop"},
+		{"for (monkeySay i = 0; i < 1 { }", "<input>:1:29: expected ';', but received '{'"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := New(*l)
+
+		p.ParseProgramme()
+
+		if len(p.errors) == 0 || p.errors[0] != tt.expected {
+			t.Fatalf("Expected error '%s' for %q, got %v", tt.expected, tt.input, p.errors)
+		}
+	}
+}
+
 func TestParseLetStatements(t *testing.T) {
 	input := `
 		monkeySay foo = 67
@@ -162,6 +256,103 @@ func TestParseLetStatements(t *testing.T) {
 
 }
 
+func TestParseNumberLiterals(t *testing.T) {
+	integers := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xff", 255},
+		{"0b1010", 10},
+		{"0o17", 15},
+		{"017", 17},
+		{"1_000_000", 1000000},
+		{"0x7fff_ffff_ffff_ffff", 9223372036854775807},
+	}
+
+	for _, tt := range integers {
+		p := New(*Lexer.New(tt.input))
+		programme := p.ParseProgramme()
+		checkForErrors(p, t)
+
+		integer, ok := programme.Statements[0].(Ast.ExpressionStatement).Value.(*Ast.IntegerExpression)
+		if !ok || integer.Value != tt.expected || integer.Big != nil {
+			t.Fatalf("%q: expected integer %d, got %#v", tt.input, tt.expected, programme.Statements[0])
+		}
+	}
+
+	floats := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"1e9", 1e9},
+		{"2.5E-3", 2.5e-3},
+		{"1_000.25", 1000.25},
+	}
+
+	for _, tt := range floats {
+		p := New(*Lexer.New(tt.input))
+		programme := p.ParseProgramme()
+		checkForErrors(p, t)
+
+		float, ok := programme.Statements[0].(Ast.ExpressionStatement).Value.(*Ast.FloatExpression)
+		if !ok || float.Value != tt.expected {
+			t.Fatalf("%q: expected float %g, got %#v", tt.input, tt.expected, programme.Statements[0])
+		}
+	}
+
+	p := New(*Lexer.New("0x1_0000_0000_0000_0000"))
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+	integer := programme.Statements[0].(Ast.ExpressionStatement).Value.(*Ast.IntegerExpression)
+	if integer.Big == nil || integer.Big.String() != "18446744073709551616" {
+		t.Fatalf("expected a big integer literal, got %#v", integer)
+	}
+
+	p = New(*Lexer.New("1e999"))
+	p.ParseProgramme()
+	if len(p.errors) == 0 || p.errors[0] != "<input>:1:1: float literal '1e999' is out of range" {
+		t.Fatalf("expected an out of range error, got %v", p.errors)
+	}
+}
+
+func TestParseDocComments(t *testing.T) {
+	input := `
+		/// Adds one to n.
+		/// Returns an integer.
+		monkeySay inc = monkeyDo(n) { n + 1 }; // not a doc comment
+
+		monkeySay plain = 1;
+		/// Doc comments only attach to let statements.
+		inc(plain) /* inline */ + 1;
+	`
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != 3 {
+		t.Fatalf("Expected 3 statements, got %d", len(programme.Statements))
+	}
+
+	docs := []string{"Adds one to n.\nReturns an integer.", ""}
+	for i, doc := range docs {
+		letStatement, ok := programme.Statements[i].(*Ast.LetStatement)
+		if !ok {
+			t.Fatalf("Statement %d not of type LetStatement", i)
+		}
+		if letStatement.Doc != doc {
+			t.Fatalf("Statement %d: expected doc %q, got %q", i, doc, letStatement.Doc)
+		}
+	}
+
+	if programme.Statements[2].ToString() != "(funinc(plain) + 1)" {
+		t.Fatalf("unexpected statement %s", programme.Statements[2].ToString())
+	}
+}
+
 func TestParseBooleanExpressions(t *testing.T) {
 	tests := []struct{
 		input string
@@ -194,12 +385,89 @@ func TestParseBooleanExpressions(t *testing.T) {
 		}
 
 		if boolExpression.Value != tt.output {
-			t.Fatalf(fmt.Sprintf("wrong bool value, got: '%t', expected: '%t'", boolExpression.Value, tt.output))
+			t.Fatalf("wrong bool value, got: '%t', expected: '%t'", boolExpression.Value, tt.output)
 		}
 	}
 
 }
 
+func TestParseStringExpressions(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`"hello"`, "hello"},
+		{`"a\tb"`, "a\tb"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := New(*l)
+
+		programme := p.ParseProgramme()
+		checkForErrors(p, t)
+
+		expressionStatement, ok := programme.Statements[0].(Ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("not expression statement")
+		}
+
+		stringExpression, ok := expressionStatement.Value.(*Ast.StringExpression)
+		if !ok {
+			t.Fatalf("not string expression")
+		}
+
+		if stringExpression.Value != tt.output {
+			t.Fatalf("wrong string value, got: %q, expected: %q", stringExpression.Value, tt.output)
+		}
+	}
+}
+
+func TestParseOperatorAssociativity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// binary operators associate to the left
+		{"10 - 5 - 2", "((10 - 5) - 2)"},
+		{"16 / 4 / 2", "((16 / 4) / 2)"},
+		{"a == b == c", "((a == b) == c)"},
+		{"a < b < c", "((a < b) < c)"},
+		{"a || b || c", "((a || b) || c)"},
+		{"a && b && c", "((a && b) && c)"},
+		// prefix operators take an operand that may be indexed, called or prefixed
+		{"-a[0]", "(-(a[0]))"},
+		{"-f(x)", "(-funf(x))"},
+		{"!-x", "(!(-x))"},
+		{"- -x", "(-(-x))"},
+		{"-x + y", "((-x) + y)"},
+		{"!a == b", "((!a) == b)"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := New(*l)
+		programme := p.ParseProgramme()
+		checkForErrors(p, t)
+
+		if actual := programme.Statements[0].ToString(); actual != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, actual)
+		}
+	}
+
+	// assignments associate to the right
+	l := Lexer.New("a = b = 1")
+	p := New(*l)
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	outer := programme.Statements[0].(Ast.ExpressionStatement).Value.(*Ast.AssignExpression)
+	inner, ok := outer.Value.(*Ast.AssignExpression)
+	if outer.Name.Value != "a" || !ok || inner.Name.Value != "b" {
+		t.Fatalf("expected a = (b = 1), got %s", outer.ToString())
+	}
+}
+
 func TestParseInfixExpressions(t *testing.T) {
 	input := `
 		1 < 2;
@@ -207,6 +475,17 @@ func TestParseInfixExpressions(t *testing.T) {
 		(1 * 2) + 3;
 		foo + 5
 		l(1) + l(0)
+		"hello " + name
+		1 - 2 - 3;
+		1 - 2 * 3 + 4;
+		10 / 2 * 5 == x;
+		a || b && c;
+		a && b || c;
+		a && b == c;
+		x > 1 && x < 5 || done;
+		!a && b;
+		-x * 2;
+		!(a && b) || c;
 	`
 	output := []string{
 		"(1 < 2)",
@@ -214,6 +493,17 @@ func TestParseInfixExpressions(t *testing.T) {
 		"((1 * 2) + 3)",
 		"(foo + 5)",
 		"(funl(1) + funl(0))",
+		"(\"hello \" + name)",
+		"((1 - 2) - 3)",
+		"((1 - (2 * 3)) + 4)",
+		"(((10 / 2) * 5) == x)",
+		"(a || (b && c))",
+		"((a && b) || c)",
+		"(a && (b == c))",
+		"(((x > 1) && (x < 5)) || done)",
+		"((!a) && b)",
+		"((-x) * 2)",
+		"((!(a && b)) || c)",
 	}
 
 	l := Lexer.New(input)
@@ -248,14 +538,10 @@ func TestParsePrefixExpressions(t *testing.T) {
 	input := `
 		-3;
 		!true;
-		--4;
-		++100;
 	`
 	output := []string{
 		"(-3)",
 		"(!true)",
-		"(--4)",
-		"(++100)",
 	}
 
 	l := Lexer.New(input)
@@ -286,6 +572,98 @@ func TestParsePrefixExpressions(t *testing.T) {
 	}
 }
 
+func TestParseAssignmentExpressions(t *testing.T) {
+	input := `
+		x = 1;
+		x = y = 2 + 3;
+		total += price * 2;
+		x -= 1; x *= 2; x /= 3;
+		--x;
+		++count;
+		i++;
+		i-- + 1;
+		for (monkeySay i = 0; i < 3; i++) { }
+	`
+	output := []string{
+		"x = 1",
+		"x = y = (2 + 3)",
+		"total += (price * 2)",
+		"x -= 1",
+		"x *= 2",
+		"x /= 3",
+		"(--x)",
+		"(++count)",
+		"(i++)",
+		"((i--) + 1)",
+		"for (i = 0; (i < 3); (i++)) ",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != len(output) {
+		t.Fatalf("Expected %d statements, got %d", len(output), len(programme.Statements))
+	}
+
+	for i, statement := range programme.Statements {
+		if statement.ToString() != output[i] {
+			t.Fatalf("Statement %d: Expected output to be %s, got %s", i, output[i], statement.ToString())
+		}
+	}
+}
+
+func TestParseAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2", "<input>:1:3: cannot assign to this expression"},
+		{"[1][0] += 2", "<input>:1:8: cannot assign to this expression"},
+		{"++4", "<input>:1:3: expected IDENT, but received '4'"},
+		{"f()++", "<input>:1:4: cannot apply '++' to this expression"},
+		{"a[] = 1", "<input>:1:3: cannot parse literal ']'"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := New(*l)
+
+		p.ParseProgramme()
+
+		if len(p.errors) == 0 || p.errors[0] != tt.expected {
+			t.Fatalf("Expected error '%s' for %q, got %v", tt.expected, tt.input, p.errors)
+		}
+	}
+}
+
+func TestParseMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { }", "<input>:1:1: match needs at least one arm"},
+		{"match (x) { [1] => 1 }", "<input>:1:13: invalid pattern '['"},
+		{"match (x) { f() => 1 }", "<input>:1:14: expected '=>', but received '('"},
+		{"match (x) { 1 => 1 2 => 2 }", "<input>:1:20: expected ',', but received '2'"},
+		{"match x { 1 => 1 }", "<input>:1:7: expected '(', but received 'x'"},
+		{"if (a) { 1 } else 2", "<input>:1:19: expected '{', but received '2'"},
+	}
+
+	for _, tt := range tests {
+		l := Lexer.New(tt.input)
+		p := New(*l)
+
+		p.ParseProgramme()
+
+		if len(p.errors) == 0 || p.errors[0] != tt.expected {
+			t.Fatalf("Expected error '%s' for %q, got %v", tt.expected, tt.input, p.errors)
+		}
+	}
+}
+
 func TestParseFunctionExpressions(t *testing.T) {
 	input := `
 		monkeyDo(l) { if (l(0) == 0) { return 0 } else { return (sum(l(1))) + l(0) } }
@@ -364,6 +742,90 @@ func TestParseFunctionCallExpressions(t *testing.T) {
 	}
 }
 
+func TestParseArrayAndIndexExpressions(t *testing.T) {
+	input := `
+		[1, 2 * 3, foo];
+		[];
+		list[1 + 1];
+		[1, 2][0] + foo(1)[2];
+		rows[0][1];
+	`
+	output := []string{
+		"[1, (2 * 3), foo]",
+		"[]",
+		"(list[(1 + 1)])",
+		"(([1, 2][0]) + (funfoo(1)[2]))",
+		"((rows[0])[1])",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != len(output) {
+		t.Fatalf("Expected %d statements, got %d", len(output), len(programme.Statements))
+	}
+
+	for i, statement := range programme.Statements {
+
+		expressionStatement, ok := statement.(Ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("statement %d Not of type ExpressionStatement", i)
+		}
+
+		if expressionStatement.ToString() != output[i] {
+			t.Fatalf("Expected output to be %s, got %s", output[i], expressionStatement.ToString())
+		}
+	}
+}
+
+func TestParseMapExpressions(t *testing.T) {
+	input := `
+		monkeySay m = {"a": 1, 2: true, x: 1 + 1};
+		{"a": 1}["a"];
+		{};
+		{ foo }
+		monkeySay empty = {};
+		{[1]: 2};
+		{-1: 2};
+		{"a" + "b": 1};
+		{ foo; bar }
+	`
+	output := []string{
+		`m = {"a": 1, 2: true, x: (1 + 1)}`,
+		`({"a": 1}["a"])`,
+		"{}",
+		"{ foo }",
+		"empty = {}",
+		"{[1]: 2}",
+		"{(-1): 2}",
+		`{("a" + "b"): 1}`,
+		"{ foobar }",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+	checkForErrors(p, t)
+
+	if len(programme.Statements) != len(output) {
+		t.Fatalf("Expected %d statements, got %d", len(output), len(programme.Statements))
+	}
+
+	if _, ok := programme.Statements[3].(Ast.BlockStatement); !ok {
+		t.Fatalf("'{ foo }' should be parsed as a block statement")
+	}
+
+	for i, statement := range programme.Statements {
+		if statement.ToString() != output[i] {
+			t.Fatalf("Statement %d: Expected output to be %s, got %s", i, output[i], statement.ToString())
+		}
+	}
+}
+
 func TestParserErrors(t *testing.T) {
 	input := `
 		monkeySay foo = !;
@@ -379,6 +841,128 @@ func TestParserErrors(t *testing.T) {
 
 }
 
+func TestParserErrorPositions(t *testing.T) {
+	input := "monkeySay foo = 1;\nmonkeySay 5 = 2;"
+
+	l := Lexer.NewWithFileName("script.chimp", input)
+	p := New(*l)
+
+	p.ParseProgramme()
+
+	if len(p.errors) == 0 {
+		t.Fatalf("Expected errors, got none")
+	}
+
+	expected := "script.chimp:2:11: expected IDENT, but received '5'"
+	if p.errors[0] != expected {
+		t.Fatalf("Expected error '%s', got '%s'", expected, p.errors[0])
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	input := `
+		if 1 < 2 { return 1 }
+		monkeySay = 5;
+		monkeyDo x { return x };
+		monkeySay ok = 10;
+	`
+	expectedErrors := []string{
+		"<input>:2:6: expected '(', but received '1'",
+		"<input>:3:13: expected IDENT, but received '='",
+		"<input>:4:12: expected '(', but received 'x'",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+
+	if len(p.errors) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expectedErrors), len(p.errors), p.errors)
+	}
+
+	for i, msg := range expectedErrors {
+		if p.errors[i] != msg {
+			t.Fatalf("error %d: expected '%s', got '%s'", i, msg, p.errors[i])
+		}
+	}
+
+	last, ok := programme.Statements[len(programme.Statements)-1].(*Ast.LetStatement)
+	if !ok || last.Name.Value != "ok" {
+		t.Fatalf("parsing did not resume after errors, last statement: %v", programme.Statements[len(programme.Statements)-1])
+	}
+}
+
+func TestParserErrorRecoveryAroundStatements(t *testing.T) {
+	// each mistake is reported once, and the statements after it are still parsed:
+	// the break and continue outside a loop, the match and the rest of each block
+	input := `monkeySay 2 = 1
+break
+monkeySay a = 1 +
+continue
+while (true) { monkeySay b = }
+monkeySay 3 = 1
+match (1) { 1 -> 1 }
+if (true) { monkeySay d = ) }
+monkeySay e = )
+monkeySay ok = 1`
+	expectedErrors := []string{
+		"<input>:1:11: expected IDENT, but received '2'",
+		"<input>:2:1: 'break' is only allowed inside a loop",
+		"<input>:4:1: cannot parse literal 'continue'",
+		"<input>:4:1: 'continue' is only allowed inside a loop",
+		"<input>:5:30: cannot parse literal '}'",
+		"<input>:6:11: expected IDENT, but received '3'",
+		"<input>:7:15: expected '=>', but received '-'",
+		"<input>:8:27: cannot parse literal ')'",
+		"<input>:9:15: cannot parse literal ')'",
+	}
+
+	l := Lexer.New(input)
+	p := New(*l)
+
+	programme := p.ParseProgramme()
+
+	if !reflect.DeepEqual(p.errors, expectedErrors) {
+		t.Fatalf("expected errors:\n%s\ngot:\n%s", strings.Join(expectedErrors, "\n"), strings.Join(p.errors, "\n"))
+	}
+
+	last, ok := programme.Statements[len(programme.Statements)-1].(*Ast.LetStatement)
+	if !ok || last.Name.Value != "ok" {
+		t.Fatalf("parsing did not resume after errors, last statement: %T", programme.Statements[len(programme.Statements)-1])
+	}
+}
+
+func TestParserMalformedInputDoesNotPanic(t *testing.T) {
+	inputs := []string{
+		"if (1 < 2 { return 1 }",
+		"if (1 < 2) return 1",
+		"if (true) { 1 } else 2",
+		"monkeyDo(x, 1) { x }",
+		"monkeyDo(x { x }",
+		"foo(1, 2",
+		"(1 + 2",
+		"{ monkeySay x = 1;",
+		"monkeySay f = monkeyDo(x) { x + ; }; monkeySay y = ;",
+		"-) = 2",
+		"a[] = 1",
+		"x + ) ++",
+		"(-)++",
+		"[1, ) += 1",
+	}
+
+	for _, input := range inputs {
+		l := Lexer.New(input)
+		p := New(*l)
+
+		p.ParseProgramme()
+
+		if len(p.errors) == 0 {
+			t.Fatalf("Expected errors for input %q, got none", input)
+		}
+	}
+}
+
 func checkForErrors(p *Parser, t *testing.T) {
 	if len(p.errors) > 0 {
 		t.Errorf("%d errors found.\n", len(p.errors))
@@ -0,0 +1,461 @@
+package Repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxHistory is how many lines the history keeps, in memory and on disk.
+const maxHistory = 1000
+
+// errInterrupted is returned by readLine when the user presses Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads the lines typed into the REPL, showing prompt before each.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// scannerReader reads plain lines, for input that is not a terminal.
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r scannerReader) readLine(p string) (string, error) {
+	if p != "" {
+		_, _ = prompt.Fprint(r.out, p)
+	}
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// newLineReader returns a lineEditor when in is a terminal that can be put in
+// raw mode, and a scannerReader otherwise.
+func newLineReader(in io.Reader, out io.Writer, complete func(prefix string) []string) lineReader {
+	if file, ok := in.(*os.File); ok {
+		if raw := rawMode(file); raw != nil {
+			editor := &lineEditor{
+				in:          bufio.NewReader(in),
+				out:         out,
+				raw:         raw,
+				complete:    complete,
+				historyFile: historyPath(),
+			}
+			editor.loadHistory()
+			return editor
+		}
+	}
+	return scannerReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+// lineEditor reads lines key by key, with emacs-style editing, a history that
+// is browsed with the arrow keys or searched with Ctrl-R, and completion on Tab.
+type lineEditor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// raw puts the terminal in raw mode for the duration of a readLine,
+	// returning a function that restores it. It is nil when in is not a terminal.
+	raw func() (restore func(), err error)
+	// complete returns the words that start with prefix.
+	complete func(prefix string) []string
+
+	history []string
+	// historyFile is where lines are saved for later sessions, if not empty.
+	historyFile string
+
+	prompt string
+	line   []rune
+	cursor int
+	// browsing is the history entry shown, len(history) for the line being typed,
+	// which is kept in typed while another entry is shown.
+	browsing int
+	typed    []rune
+}
+
+func ctrl(key rune) rune {
+	return key & 0x1f
+}
+
+const (
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.prompt, e.line, e.cursor = prompt, nil, 0
+	e.browsing, e.typed = len(e.history), nil
+	e.refresh()
+
+	for {
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		if key == ctrl('R') {
+			if key, err = e.search(); err != nil {
+				return "", err
+			}
+		}
+
+		switch key {
+		case 0:
+		case '\r', '\n':
+			e.write("\r\n")
+			line := string(e.line)
+			e.addHistory(line)
+			return line, nil
+		case ctrl('C'):
+			e.write("^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(e.line) == 0 {
+				e.write("\r\n")
+				return "", io.EOF
+			}
+			e.deleteRange(e.cursor, e.cursor+1)
+		case ctrl('A'):
+			e.cursor = 0
+		case ctrl('E'):
+			e.cursor = len(e.line)
+		case ctrl('B'):
+			e.moveTo(e.cursor - 1)
+		case ctrl('F'):
+			e.moveTo(e.cursor + 1)
+		case ctrl('H'), keyBackspace:
+			e.deleteRange(e.cursor-1, e.cursor)
+		case ctrl('K'):
+			e.deleteRange(e.cursor, len(e.line))
+		case ctrl('U'):
+			e.deleteRange(0, e.cursor)
+		case ctrl('W'):
+			e.deleteRange(e.wordStart(), e.cursor)
+		case ctrl('P'):
+			e.browse(e.browsing - 1)
+		case ctrl('N'):
+			e.browse(e.browsing + 1)
+		case ctrl('L'):
+			e.write("\x1b[H\x1b[2J")
+		case '\t':
+			e.completeWord()
+		case keyEscape:
+			if err := e.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(key) {
+				e.insert([]rune{key})
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape handles the keys sent as escape sequences: arrows, Home, End and
+// Delete, and Alt-b, Alt-f and Alt-d for words.
+func (e *lineEditor) escape() error {
+	key, _, err := e.in.ReadRune()
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case 'b':
+		e.cursor = e.wordStart()
+		return nil
+	case 'f':
+		e.cursor = e.wordEnd()
+		return nil
+	case 'd':
+		e.deleteRange(e.cursor, e.wordEnd())
+		return nil
+	case '[', 'O':
+	default:
+		return nil
+	}
+
+	sequence := ""
+	for {
+		key, _, err = e.in.ReadRune()
+		if err != nil {
+			return err
+		}
+		sequence += string(key)
+		if !unicode.IsDigit(key) && key != ';' {
+			break
+		}
+	}
+
+	switch sequence {
+	case "A":
+		e.browse(e.browsing - 1)
+	case "B":
+		e.browse(e.browsing + 1)
+	case "C":
+		e.moveTo(e.cursor + 1)
+	case "D":
+		e.moveTo(e.cursor - 1)
+	case "H", "1~", "7~":
+		e.cursor = 0
+	case "F", "4~", "8~":
+		e.cursor = len(e.line)
+	case "3~":
+		e.deleteRange(e.cursor, e.cursor+1)
+	}
+	return nil
+}
+
+// search runs a reverse incremental search of the history. The key that ends
+// the search is returned to be handled as usual, with the match as the line, or
+// 0 when the search is cancelled with Ctrl-G.
+func (e *lineEditor) search() (rune, error) {
+	query := []rune{}
+	found := len(e.history)
+
+	for {
+		match := ""
+		if found < len(e.history) {
+			match = e.history[found]
+		}
+		e.write(fmt.Sprintf("\r(reverse-i-search)`%s': %s\x1b[K", string(query), match))
+
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case key == ctrl('R'):
+			found = e.find(string(query), found-1, found)
+		case key == ctrl('G'):
+			return 0, nil
+		case key == ctrl('H') || key == keyBackspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			found = e.find(string(query), len(e.history)-1, len(e.history))
+		case unicode.IsPrint(key):
+			query = append(query, key)
+			from := found
+			if from == len(e.history) {
+				from--
+			}
+			found = e.find(string(query), from, found)
+		default:
+			if found < len(e.history) {
+				e.line = []rune(e.history[found])
+				e.cursor = len(e.line)
+			}
+			return key, nil
+		}
+	}
+}
+
+// find returns the latest history entry at or before from that contains query,
+// or otherwise.
+func (e *lineEditor) find(query string, from int, otherwise int) int {
+	for i := from; i >= 0; i-- {
+		if strings.Contains(e.history[i], query) {
+			return i
+		}
+	}
+	return otherwise
+}
+
+// completeWord completes the word before the cursor as far as every candidate
+// agrees, listing the candidates when that adds nothing.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	start := e.cursor
+	for start > 0 && isWordRune(e.line[start-1]) {
+		start--
+	}
+	prefix := string(e.line[start:e.cursor])
+	if prefix == "" {
+		return
+	}
+
+	candidates := e.complete(prefix)
+	if len(candidates) == 0 {
+		return
+	}
+
+	common := []rune(candidates[0])
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, string(common)) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	if typed := []rune(prefix); len(common) > len(typed) {
+		e.insert(common[len(typed):])
+	} else if len(candidates) > 1 {
+		e.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+	}
+}
+
+// browse shows history entry i, keeping the line being typed while away from it.
+func (e *lineEditor) browse(i int) {
+	if i < 0 || i > len(e.history) {
+		return
+	}
+	if e.browsing == len(e.history) {
+		e.typed = e.line
+	}
+
+	e.browsing = i
+	if i == len(e.history) {
+		e.line = e.typed
+	} else {
+		e.line = []rune(e.history[i])
+	}
+	e.cursor = len(e.line)
+}
+
+func (e *lineEditor) insert(text []rune) {
+	line := make([]rune, 0, len(e.line)+len(text))
+	line = append(line, e.line[:e.cursor]...)
+	line = append(line, text...)
+	e.line = append(line, e.line[e.cursor:]...)
+	e.cursor += len(text)
+}
+
+// deleteRange removes the runes from start up to end, clamped to the line.
+func (e *lineEditor) deleteRange(start int, end int) {
+	start, end = max(start, 0), min(end, len(e.line))
+	if start >= end {
+		return
+	}
+
+	line := make([]rune, 0, len(e.line)-(end-start))
+	line = append(line, e.line[:start]...)
+	e.line = append(line, e.line[end:]...)
+	if e.cursor > end {
+		e.cursor -= end - start
+	} else if e.cursor > start {
+		e.cursor = start
+	}
+}
+
+func (e *lineEditor) moveTo(cursor int) {
+	e.cursor = min(max(cursor, 0), len(e.line))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordStart is where the word before the cursor starts.
+func (e *lineEditor) wordStart() int {
+	i := e.cursor
+	for i > 0 && !isWordRune(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd is where the word after the cursor ends.
+func (e *lineEditor) wordEnd() int {
+	i := e.cursor
+	for i < len(e.line) && !isWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && isWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+// refresh redraws the prompt and line, and puts the terminal cursor in place.
+func (e *lineEditor) refresh() {
+	e.write("\r" + e.prompt + string(e.line) + "\x1b[K")
+	if back := len(e.line) - e.cursor; back > 0 {
+		e.write(fmt.Sprintf("\x1b[%dD", back))
+	}
+}
+
+func (e *lineEditor) write(s string) {
+	_, _ = io.WriteString(e.out, s)
+}
+
+// historyPath is the file in the user's config directory that keeps the
+// history between sessions, or empty when there is no such directory.
+func historyPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "chimp", "history")
+}
+
+// loadHistory reads the history saved by earlier sessions. The history is a
+// convenience, so a missing or unreadable file just leaves it empty.
+func (e *lineEditor) loadHistory() {
+	if e.historyFile == "" {
+		return
+	}
+	content, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		content := strings.Join(e.history, "\n") + "\n"
+		_ = os.WriteFile(e.historyFile, []byte(content), 0o600)
+	}
+}
+
+// addHistory records line, unless it is blank or repeats the previous line,
+// and appends it to the history file.
+func (e *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" || len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+	}
+
+	if e.historyFile == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(e.historyFile), 0o700); err != nil {
+		return
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	_, _ = file.WriteString(line + "\n")
+}
@@ -2,55 +2,247 @@ package Repl
 
 import (
 	"Chimp/Evaluator"
+	"Chimp/Interpreter"
 	"Chimp/Lexer"
 	"Chimp/Object"
 	"Chimp/Parser"
-	"bufio"
+	"Chimp/Token"
 	"fmt"
 	"github.com/fatih/color"
 	"io"
+	"os"
+	"sort"
+	"strings"
 )
 
+var (
+	prompt = color.New(color.FgBlue)
+	failed = color.New(color.FgRed)
+	result = color.New(color.FgYellow)
+)
+
+const help = `Commands:
+  :tokens <code>  list the tokens of code
+  :ast <code>     show how code parses
+  :env            list the bindings of the session
+  :load <file>    run a file in the session
+  :reset          forget every binding
+  :quit           leave the session
+  :help           show this list
+`
+
+type session struct {
+	interpreter *Interpreter.Interpreter
+	out         io.Writer
+}
+
+func newSession(out io.Writer) *session {
+	interpreter := Interpreter.New()
+	interpreter.SetOutput(out)
+	return &session{interpreter: interpreter, out: out}
+}
+
+// Start reads and runs in a statement at a time, writing prompts, results and
+// errors to out. Lines are gathered behind a continuation prompt until the
+// input is complete, and lines starting with ':' are commands. When in is a
+// terminal, lines are read with a line editor.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := Object.NewEnvironment(nil)
+	s := newSession(out)
+	reader := newLineReader(in, out, s.complete)
+
+	input := strings.Builder{}
 
 	for {
-		color.Blue("Go on...")
-		scanned := scanner.Scan()
+		continuation := ""
+		if input.Len() == 0 {
+			_, _ = prompt.Fprintln(out, "Go on...")
+		} else {
+			continuation = "... "
+		}
+
+		line, err := reader.readLine(continuation)
+		if err == errInterrupted {
+			input.Reset()
+			continue
+		}
+
+		scanned := err == nil
+		if scanned {
+			if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+				if quit := s.command(strings.TrimSpace(line)); quit {
+					return
+				}
+				continue
+			}
+
+			input.WriteString(line)
+			input.WriteByte('\n')
+			if incomplete(input.String()) {
+				continue
+			}
+		}
+
+		if input.Len() > 0 {
+			s.report(s.interpreter.Run(input.String()))
+			input.Reset()
+		}
 		if !scanned {
 			return
 		}
+	}
+}
 
-		text := scanner.Text()
-		lexer := Lexer.New(text)
-		parser := Parser.New(*lexer)
+// complete returns, sorted, the keywords, builtins and bindings of the session
+// that start with prefix.
+func (s *session) complete(prefix string) []string {
+	words := append(Lexer.Keywords(), Evaluator.BuiltinNames()...)
+	words = append(words, s.interpreter.Environment().Names()...)
 
-		programme := parser.ParseProgramme()
+	seen := map[string]bool{}
+	candidates := []string{}
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) && !seen[word] {
+			seen[word] = true
+			candidates = append(candidates, word)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
 
-		if errors := parser.GetErrors(); len(errors) > 0 {
-			color.Set(color.FgRed)
-			_, _ = io.WriteString(out, "Parsing Error:\n")
-			for i, err := range errors {
-				_, _ = io.WriteString(out, fmt.Sprintf("%d: %s\n", i, err))
-			}
-			color.Unset()
-		} else {
-			p, err := Evaluator.Eval(programme, env)
-
-			if err != nil {
-				color.Set(color.FgRed)
-				_, _ = io.WriteString(out, "Evaluator error:\n")
-				_, _ = io.WriteString(out, err.Error())
-				_, _ = io.WriteString(out, "\n")
-				color.Unset()
-			} else {
-				color.Set(color.FgYellow)
-				_, _ = io.WriteString(out, "$: ")
-				_, _ = io.WriteString(out, p.Inspect())
-				_, _ = io.WriteString(out, "\n")
-				color.Unset()
+// command carries out a line such as ":ast 1 + 2", reporting whether the
+// session should end.
+func (s *session) command(line string) bool {
+	name, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+
+	switch name {
+	case ":tokens":
+		s.tokens(argument)
+	case ":ast":
+		s.ast(argument)
+	case ":env":
+		s.env()
+	case ":load":
+		s.load(argument)
+	case ":reset":
+		s.interpreter = newSession(s.out).interpreter
+	case ":quit":
+		return true
+	case ":help":
+		_, _ = io.WriteString(s.out, help)
+	default:
+		_, _ = failed.Fprintf(s.out, "Unknown command '%s'\n%s", name, help)
+	}
+	return false
+}
+
+func (s *session) tokens(code string) {
+	l := Lexer.New(code)
+	for token := l.NextToken(); ; token = l.NextToken() {
+		_, _ = fmt.Fprintf(s.out, "%s\t%-10s %q\n", token.Position, token.Type, token.Literal)
+		if token.Type == Token.EOF {
+			return
+		}
+	}
+}
+
+func (s *session) ast(code string) {
+	l := Lexer.New(code)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+
+	if errors := p.GetErrors(); len(errors) > 0 {
+		s.report(nil, Interpreter.ParseError{Errors: errors})
+		return
+	}
+	for _, statement := range programme.Statements {
+		kind := strings.TrimPrefix(fmt.Sprintf("%T", statement), "*")
+		_, _ = fmt.Fprintf(s.out, "%s\t%s\n", kind, statement.ToString())
+	}
+}
+
+func (s *session) env() {
+	env := s.interpreter.Environment()
+	for _, name := range env.Names() {
+		value, _ := env.Get(name)
+		_, _ = fmt.Fprintf(s.out, "%s = %s\n", name, value.Inspect())
+	}
+}
+
+func (s *session) load(path string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		_, _ = failed.Fprintf(s.out, "%s\n", err)
+		return
+	}
+	s.report(s.interpreter.RunFile(path, string(source)))
+}
+
+func (s *session) report(p Object.Object, err error) {
+	if parseError, ok := err.(Interpreter.ParseError); ok {
+		_, _ = failed.Fprint(s.out, "Parsing Error:\n")
+		for i, err := range parseError.Errors {
+			_, _ = failed.Fprintf(s.out, "%d: %s\n", i, err)
+		}
+	} else if err != nil {
+		_, _ = failed.Fprintf(s.out, "Evaluator error:\n%s\n", err)
+	} else {
+		_, _ = result.Fprintf(s.out, "$: %s\n", p.Inspect())
+	}
+}
+
+// continuing are the tokens that cannot end a statement.
+var continuing = map[Token.TokenType]bool{
+	Token.ASSIGN: true, Token.PLUS_ASSIGN: true, Token.MINUS_ASSIGN: true,
+	Token.MULTIPLY_ASSIGN: true, Token.DIVIDE_ASSIGN: true,
+	Token.PLUS: true, Token.MINUS: true, Token.MULTIPLY: true, Token.DIVIDE: true,
+	Token.EQ: true, Token.NEQ: true, Token.GT: true, Token.LT: true, Token.GTE: true, Token.LTE: true,
+	Token.AND: true, Token.OR: true, Token.BANG: true,
+	Token.COMMA: true, Token.COLON: true, Token.ARROW: true,
+}
+
+// incomplete reports whether source needs more lines: it has unclosed
+// brackets, ends with an operator, or ends inside a string or block comment.
+func incomplete(source string) bool {
+	l := Lexer.New(source)
+	depth := 0
+	last := Token.Token{Type: Token.EOF}
+
+	for token := l.NextToken(); token.Type != Token.EOF; token = l.NextToken() {
+		switch token.Type {
+		case Token.LPAREN, Token.LBRACE, Token.LBRACKET:
+			depth++
+		case Token.RPAREN, Token.RBRACE, Token.RBRACKET:
+			depth--
+		case Token.ILLEGAL:
+			if token.Position.Offset+len(token.Literal) == len(source) && unterminated(token.Literal) {
+				return true
 			}
 		}
+		last = token
+	}
+
+	return depth > 0 || continuing[last.Type]
+}
+
+// unterminated reports whether the literal of an ILLEGAL token is a string or
+// block comment that the input ended before closing.
+func unterminated(literal string) bool {
+	if strings.HasPrefix(literal, "/*") {
+		return true
+	}
+	if !strings.HasPrefix(literal, `"`) {
+		return false
+	}
+
+	for i := 1; i < len(literal); i++ {
+		switch literal[i] {
+		case '\\':
+			i++
+		case '"':
+			return false
+		}
 	}
+	return true
 }
@@ -0,0 +1,216 @@
+package Repl
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/fatih/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"monkeySay x = 1;", false},
+		{"monkeySay add = monkeyDo(a, b) {", true},
+		{"monkeySay add = monkeyDo(a, b) {\n  a + b\n}", false},
+		{"if (x) {", true},
+		{"[1, 2,", true},
+		{"{\"a\":", true},
+		{"1 +", true},
+		{"monkeySay x =", true},
+		{"x &&", true},
+		{"x++", false},
+		{"}", false},
+		{`"open`, true},
+		{`"escaped \"`, true},
+		{`"closed"`, false},
+		{`"bad \q"`, false},
+		{"/* open", true},
+		{"/* closed */ 1", false},
+		{"// comment {", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q): expected %t, got %t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	color.NoColor = true
+
+	input := "monkeySay add = monkeyDo(a, b) {\n  a +\n    b\n};\nadd(1, 2)\n[1,\n"
+	out := bytes.Buffer{}
+	Start(strings.NewReader(input), &out)
+
+	expected := "Go on...\n... ... ... $: (a, b) { (a + b) }\n" +
+		"Go on...\n$: 3\n" +
+		"Go on...\n... "
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Fatalf("expected output to start with %q, got %q", expected, out.String())
+	}
+	if !strings.Contains(out.String(), "Parsing Error:") {
+		t.Errorf("expected the unfinished input to be parsed at the end, got %q", out.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	color.NoColor = true
+
+	file := filepath.Join(t.TempDir(), "load.chimp")
+	if err := os.WriteFile(file, []byte("monkeySay loaded = 1 + 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":tokens x + 1", "<input>:1:1\tIDENT      \"x\"\n<input>:1:3\t+          \"+\"\n" +
+			"<input>:1:5\tINT        \"1\"\n<input>:1:6\tEOF        \"EOF\"\n"},
+		{":ast monkeySay x = 1 + 2 * 3; x", "Ast.LetStatement\tx = (1 + (2 * 3))\nAst.ExpressionStatement\tx\n"},
+		{":ast 1 +", "Parsing Error:\n0: <input>:1:4: cannot parse literal 'EOF'\n"},
+		{"monkeySay b = 2; monkeySay a = \"one\"\n:env", "$: one\nGo on...\na = one\nb = 2\n"},
+		{":load " + file + "\n:env", "$: 2\nGo on...\nloaded = 2\n"},
+		{"monkeySay a = 1\n:reset\n:env\na", "$: 1\nGo on...\nGo on...\nGo on...\nEvaluator error:\n<input>:1:1: Cannot find indentifier 'a'.\n"},
+		{":quit\n1", ""},
+		{":nope", "Unknown command ':nope'\n" + help},
+	}
+
+	for _, tt := range tests {
+		out := bytes.Buffer{}
+		Start(strings.NewReader(tt.input), &out)
+
+		got := strings.TrimPrefix(out.String(), "Go on...\n")
+		got = strings.TrimSuffix(got, "Go on...\n")
+		if got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func newTestEditor(keys string, history ...string) *lineEditor {
+	return &lineEditor{
+		in:      bufio.NewReader(strings.NewReader(keys)),
+		out:     &bytes.Buffer{},
+		history: history,
+		complete: func(prefix string) []string {
+			candidates := []string{}
+			for _, word := range []string{"monkeyDo", "monkeySay", "match", "puts", "größe", "grün"} {
+				if strings.HasPrefix(word, prefix) {
+					candidates = append(candidates, word)
+				}
+			}
+			return candidates
+		},
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	history := []string{"puts(1)", "monkeySay x = 2", "x + 1"}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"1 + 2\r", "1 + 2"},
+		{"1 + 2\x7f3\r", "1 + 3"},
+		{"2 + 3\x01\x06\x06\x04-\r", "2 - 3"},
+		{"x + 1\x1b[D\x1b[D\x1b[D\x1b[3~\r", "x  1"},
+		{"abc\x01X\x05Y\r", "XabcY"},
+		{"one two\x17three\r", "one three"},
+		{"one two\x01\x0b\r", ""},
+		{"one two\x1bbX\r", "one Xtwo"},
+		{"one two\x1b[H\x1bd\r", " two"},
+		{"\x10\r", "x + 1"},
+		{"\x1b[A\x1b[A\r", "monkeySay x = 2"},
+		{"draft\x10\x10\x0e\x0e\r", "draft"},
+		{"\x12puts\r", "puts(1)"},
+		{"\x12x\x12\x06!\r", "monkeySay x = 2!"},
+		{"draft\x12nothing\x07\r", "draft"},
+		{"mon\tS\t = 1\r", "monkeySay = 1"},
+		{"pu\t\r", "puts"},
+		{"g\t\r", "gr"},
+		{"grö\t\r", "größe"},
+		{"héllo\x02\x02\x7f\r", "hélo"},
+	}
+
+	for _, tt := range tests {
+		line, err := newTestEditor(tt.keys, history...).readLine("")
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", tt.keys, err)
+		}
+		if line != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.keys, tt.expected, line)
+		}
+	}
+
+	if _, err := newTestEditor("abc\x03").readLine(""); err != errInterrupted {
+		t.Errorf("expected Ctrl-C to interrupt, got %v", err)
+	}
+	if _, err := newTestEditor("\x04").readLine(""); err != io.EOF {
+		t.Errorf("expected Ctrl-D on an empty line to end the input, got %v", err)
+	}
+}
+
+func TestLineEditorListsCompletions(t *testing.T) {
+	editor := newTestEditor("m\t\r")
+	if line, _ := editor.readLine(""); line != "m" {
+		t.Errorf("expected an ambiguous word to be left alone, got %q", line)
+	}
+	if out := editor.out.(*bytes.Buffer).String(); !strings.Contains(out, "\r\nmonkeyDo  monkeySay  match\r\n") {
+		t.Errorf("expected the candidates to be listed, got %q", out)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chimp", "history")
+
+	editor := newTestEditor("1 + 1\r\r2 + 2\r2 + 2\r")
+	editor.historyFile = path
+	for i := 0; i < 4; i++ {
+		if _, err := editor.readLine(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := newTestEditor("\x10\x10\r")
+	next.historyFile = path
+	next.loadHistory()
+	if len(next.history) != 2 {
+		t.Errorf("expected blank and repeated lines to be left out, got %q", next.history)
+	}
+	if line, _ := next.readLine(""); line != "1 + 1" {
+		t.Errorf("expected the history of the earlier session, got %q", line)
+	}
+}
+
+func TestSessionComplete(t *testing.T) {
+	s := newSession(&bytes.Buffer{})
+	if _, err := s.interpreter.Run("monkeySay monkeyCount = 1; monkeySay total = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{"monkey", "monkeyCount monkeyDo monkeySay"},
+		{"t", "total true type"},
+		{"le", "len"},
+		{"zz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(s.complete(tt.prefix), " "); got != tt.expected {
+			t.Errorf("complete(%q): expected %q, got %q", tt.prefix, tt.expected, got)
+		}
+	}
+}
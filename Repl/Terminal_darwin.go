@@ -0,0 +1,11 @@
+//go:build darwin
+
+package Repl
+
+import "syscall"
+
+// the ioctl requests that get and set the terminal attributes
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
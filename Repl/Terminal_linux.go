@@ -0,0 +1,11 @@
+//go:build linux
+
+package Repl
+
+import "syscall"
+
+// the ioctl requests that get and set the terminal attributes
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package Repl
+
+import "os"
+
+// rawMode is only supported on Linux and macOS; elsewhere the REPL reads plain
+// lines, without editing, history or completion.
+func rawMode(file *os.File) func() (func(), error) {
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin
+
+package Repl
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// rawMode returns a function that switches file to raw mode, in which keys are
+// read one at a time without echo, or nil when file is not a terminal.
+func rawMode(file *os.File) func() (func(), error) {
+	fd := file.Fd()
+	if _, err := getTermios(fd); err != nil {
+		return nil
+	}
+
+	return func() (func(), error) {
+		cooked, err := getTermios(fd)
+		if err != nil {
+			return nil, err
+		}
+
+		raw := *cooked
+		raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+			syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+		raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+		raw.Cflag |= syscall.CS8
+		raw.Cc[syscall.VMIN] = 1
+		raw.Cc[syscall.VTIME] = 0
+		if err := setTermios(fd, &raw); err != nil {
+			return nil, err
+		}
+
+		return func() { _ = setTermios(fd, cooked) }, nil
+	}
+}
@@ -1,8 +1,31 @@
 package Token
 
+import "fmt"
+
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type     TokenType
+	Literal  string
+	Position Position
+	// Doc is the text of the "///" comments directly before the token, one line
+	// per comment without the slashes.
+	Doc string
+}
+
+// Position locates a token in its source: Line and Column are 1-based,
+// Offset is the 0-based byte offset into the input.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+func (p Position) String() string {
+	file := p.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d:%d", file, p.Line, p.Column)
 }
 
 type TokenType string
@@ -18,9 +41,16 @@ const (
 	RETURN   = "RETURN"
 	IF       = "IF"
 	ELSE     = "ELSE"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	MATCH    = "MATCH"
 	LET      = "LET"
 	IDENT    = "IDENT"
 	INT      = "INT"
+	FLOAT    = "FLOAT"
+	STRING   = "STRING"
 
 	EQ       = "=="
 	NEQ      = "!="
@@ -33,6 +63,14 @@ const (
 	DECR     = "--"
 	DIVIDE   = "/"
 
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	MULTIPLY_ASSIGN = "*="
+	DIVIDE_ASSIGN   = "/="
+
+	AND = "&&"
+	OR  = "||"
+
 	GT  = ">"
 	LT  = "<"
 	GTE = ">="
@@ -43,6 +81,11 @@ const (
 	LBRACE = "("
 	RBRACE = ")"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	ARROW     = "=>"
 )
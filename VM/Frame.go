@@ -0,0 +1,45 @@
+package VM
+
+import (
+	"Chimp/Compiler"
+	"Chimp/Object"
+)
+
+// Closure is a compiled function together with the free variables it captured.
+type Closure struct {
+	Fn   *Compiler.CompiledFunction
+	Free []Object.Object
+}
+
+func (c *Closure) Type() Object.ObjectType { return Object.FUNCTION_OBJ }
+func (c *Closure) Inspect() string         { return c.Fn.Source }
+
+// Cell holds a boxed local, shared by the frame that defines it and the closures
+// that capture it. Value is nil until the local is first bound.
+type Cell struct {
+	Value Object.Object
+}
+
+func (c *Cell) Type() Object.ObjectType { return c.Value.Type() }
+func (c *Cell) Inspect() string         { return c.Value.Inspect() }
+
+// Frame is a call of a closure. Its arguments and locals start at basePointer on the
+// stack, just above the closure being called.
+type Frame struct {
+	cl          *Closure
+	ip          int
+	basePointer int
+}
+
+func NewFrame(cl *Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() Compiler.Instructions {
+	return f.cl.Fn.Instructions
+}
+
+// sourceInfo returns where the instruction at ip was compiled from.
+func (f *Frame) sourceInfo(ip int) Compiler.SourceInfo {
+	return f.cl.Fn.SourceMap[ip]
+}
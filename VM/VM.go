@@ -0,0 +1,374 @@
+package VM
+
+import (
+	"Chimp/Compiler"
+	"Chimp/Evaluator"
+	"Chimp/Object"
+	"context"
+	"fmt"
+	"io"
+)
+
+const initialStackSize = 2048
+
+// contextCheckInterval is how many instructions pass between checks of the context.
+const contextCheckInterval = 1024
+
+// VM executes Bytecode with a value stack and a stack of call frames.
+type VM struct {
+	constants []Object.Object
+	globals   []Object.Object
+
+	stack []Object.Object
+	sp    int // stack[sp-1] is the top of the stack
+
+	frames      []*Frame
+	framesIndex int
+
+	env    *Object.Environment
+	result Object.Object
+	limits Evaluator.Limits
+}
+
+func New(bytecode *Compiler.Bytecode) *VM {
+	mainFn := &Compiler.CompiledFunction{Instructions: bytecode.Instructions, SourceMap: bytecode.SourceMap}
+	mainFrame := NewFrame(&Closure{Fn: mainFn}, 0)
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     make([]Object.Object, len(bytecode.Globals)),
+		stack:       make([]Object.Object, initialStackSize),
+		frames:      []*Frame{mainFrame},
+		framesIndex: 1,
+		env:         Object.NewEnvironment(nil),
+		result:      Object.Null{},
+	}
+}
+
+// SetOutput sets where builtins such as puts write, os.Stdout by default.
+func (vm *VM) SetOutput(w io.Writer) {
+	vm.env.SetOutput(w)
+}
+
+// SetArgs sets the command-line arguments that the args builtin returns.
+func (vm *VM) SetArgs(args []string) {
+	vm.env.SetArgs(args)
+}
+
+// Result returns the value of the programme once Run has finished.
+func (vm *VM) Result() Object.Object {
+	return vm.result
+}
+
+// Run runs the programme within Evaluator.DefaultLimits.
+func (vm *VM) Run() error {
+	return vm.RunContext(context.Background(), Evaluator.DefaultLimits)
+}
+
+// RunContext is Run stopping with an Evaluator.LimitError when ctx is done or limits
+// are exceeded. The VM counts instructions as its steps, not AST nodes as Eval does.
+func (vm *VM) RunContext(ctx context.Context, limits Evaluator.Limits) error {
+	vm.limits = limits
+
+	for executed := 0; ; executed++ {
+		if executed%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return Evaluator.LimitError{Reason: err}
+			}
+		}
+		if limits.MaxSteps > 0 && executed >= limits.MaxSteps {
+			return Evaluator.LimitError{Reason: Evaluator.ErrStepLimitExceeded}
+		}
+
+		frame := vm.currentFrame()
+		if frame.ip >= len(frame.Instructions())-1 {
+			break
+		}
+		frame.ip++
+
+		halted, err := vm.execute(frame)
+		if err != nil {
+			return err
+		}
+		if halted {
+			return nil
+		}
+	}
+
+	vm.result = vm.pop()
+	return nil
+}
+
+// execute runs the instruction at frame.ip, reporting whether the programme returned.
+func (vm *VM) execute(frame *Frame) (bool, error) {
+	ins := frame.Instructions()
+	ip := frame.ip
+	op := Compiler.Opcode(ins[ip])
+
+	switch op {
+	case Compiler.OpConstant:
+		index := Compiler.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		vm.push(vm.constants[index])
+	case Compiler.OpPop:
+		vm.pop()
+	case Compiler.OpDup:
+		vm.push(vm.stack[vm.sp-1])
+	case Compiler.OpNull:
+		vm.push(Object.Null{})
+	case Compiler.OpTrue:
+		vm.push(Object.Boolean{Value: true})
+	case Compiler.OpFalse:
+		vm.push(Object.Boolean{Value: false})
+
+	case Compiler.OpAdd, Compiler.OpSub, Compiler.OpMul, Compiler.OpDiv,
+		Compiler.OpEqual, Compiler.OpNotEqual,
+		Compiler.OpGreaterThan, Compiler.OpGreaterEqual, Compiler.OpLessThan, Compiler.OpLessEqual:
+		right := vm.pop()
+		left := vm.pop()
+		result, err := Evaluator.EvalInfixOperation(frame.sourceInfo(ip).Position, Compiler.Operators[op], left, right)
+		if err != nil {
+			return false, err
+		}
+		vm.push(result)
+	case Compiler.OpMinus, Compiler.OpBang:
+		result, err := Evaluator.EvalPrefixOperation(frame.sourceInfo(ip).Position, Compiler.Operators[op], vm.pop())
+		if err != nil {
+			return false, err
+		}
+		vm.push(result)
+
+	case Compiler.OpJump:
+		frame.ip = int(Compiler.ReadUint16(ins[ip+1:])) - 1
+	case Compiler.OpJumpNotTrue:
+		target := int(Compiler.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		if !Evaluator.IsTruthy(vm.pop()) {
+			frame.ip = target - 1
+		}
+
+	case Compiler.OpJumpIfFalse, Compiler.OpJumpIfTrue:
+		target := int(Compiler.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		if Evaluator.IsTruthy(vm.stack[vm.sp-1]) == (op == Compiler.OpJumpIfTrue) {
+			frame.ip = target - 1
+		} else {
+			vm.pop()
+		}
+
+	case Compiler.OpNoMatch:
+		return false, Evaluator.NoMatchingArmError(frame.sourceInfo(ip).Position, vm.pop().Inspect())
+
+	case Compiler.OpSetGlobal:
+		index := Compiler.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		vm.globals[index] = vm.pop()
+	case Compiler.OpGetGlobal:
+		index := Compiler.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		if err := vm.pushBound(frame.sourceInfo(ip), vm.globals[index]); err != nil {
+			return false, err
+		}
+	case Compiler.OpSetLocal:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		vm.stack[frame.basePointer+int(index)] = vm.pop()
+	case Compiler.OpGetLocal:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		if err := vm.pushBound(frame.sourceInfo(ip), vm.stack[frame.basePointer+int(index)]); err != nil {
+			return false, err
+		}
+	case Compiler.OpGetCell:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		if err := vm.pushBound(frame.sourceInfo(ip), vm.cell(frame, int(index)).Value); err != nil {
+			return false, err
+		}
+	case Compiler.OpSetCell:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		vm.cell(frame, int(index)).Value = vm.pop()
+	case Compiler.OpCell:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		vm.push(vm.cell(frame, int(index)))
+	case Compiler.OpGetFreeCell:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		if err := vm.pushBound(frame.sourceInfo(ip), frame.cl.Free[index].(*Cell).Value); err != nil {
+			return false, err
+		}
+	case Compiler.OpSetFreeCell:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		frame.cl.Free[index].(*Cell).Value = vm.pop()
+	case Compiler.OpGetFree:
+		index := Compiler.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		vm.push(frame.cl.Free[index])
+	case Compiler.OpCurrentClosure:
+		vm.push(frame.cl)
+
+	case Compiler.OpArray:
+		count := int(Compiler.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		elements := make([]Object.Object, count)
+		copy(elements, vm.stack[vm.sp-count:vm.sp])
+		vm.sp -= count
+		vm.push(Object.Array{Elements: elements})
+	case Compiler.OpMap:
+		count := int(Compiler.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		pairs := make(map[Object.HashKey]Object.MapPair, count/2)
+		for i := vm.sp - count; i < vm.sp; i += 2 {
+			key, value := vm.stack[i], vm.stack[i+1]
+			hashKey, err := Evaluator.HashKeyOf(frame.sourceInfo(ip).Position, key)
+			if err != nil {
+				return false, err
+			}
+			pairs[hashKey] = Object.MapPair{Key: key, Value: value}
+		}
+		vm.sp -= count
+		vm.push(Object.Map{Pairs: pairs})
+	case Compiler.OpIndex:
+		index := vm.pop()
+		target := vm.pop()
+		result, err := Evaluator.EvalIndexOperation(frame.sourceInfo(ip).Position, target, index)
+		if err != nil {
+			return false, err
+		}
+		vm.push(result)
+
+	case Compiler.OpClosure:
+		index := Compiler.ReadUint16(ins[ip+1:])
+		numFree := int(Compiler.ReadUint8(ins[ip+3:]))
+		frame.ip += 3
+		free := make([]Object.Object, numFree)
+		copy(free, vm.stack[vm.sp-numFree:vm.sp])
+		vm.sp -= numFree
+		vm.push(&Closure{Fn: vm.constants[index].(*Compiler.CompiledFunction), Free: free})
+	case Compiler.OpCall:
+		numArgs := int(Compiler.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		return false, vm.call(frame.sourceInfo(ip), numArgs)
+	case Compiler.OpReturnValue:
+		returnValue := vm.pop()
+		if vm.framesIndex == 1 {
+			vm.result = returnValue
+			return true, nil
+		}
+		returning := vm.popFrame()
+		vm.sp = returning.basePointer - 1
+		vm.push(returnValue)
+
+	default:
+		definition, err := Compiler.Lookup(op)
+		if err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("unhandled opcode %s", definition.Name)
+	}
+
+	return false, nil
+}
+
+func (vm *VM) call(info Compiler.SourceInfo, numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	switch callee := callee.(type) {
+	case *Closure:
+		if numArgs != callee.Fn.NumParameters {
+			return Evaluator.WrongArgumentCountError(info.Position, info.Name, callee.Fn.NumParameters, numArgs)
+		}
+		if maxDepth := vm.limits.MaxCallDepth; maxDepth > 0 && vm.framesIndex > maxDepth {
+			return Evaluator.LimitError{Position: info.Position, Reason: Evaluator.ErrCallDepthLimitExceeded}
+		}
+
+		frame := NewFrame(callee, vm.sp-numArgs)
+		vm.pushFrame(frame)
+		vm.reserve(frame.basePointer + callee.Fn.NumLocals)
+		for i := vm.sp; i < frame.basePointer+callee.Fn.NumLocals; i++ {
+			vm.stack[i] = nil
+		}
+		vm.sp = frame.basePointer + callee.Fn.NumLocals
+	case Object.Builtin:
+		args := make([]Object.Object, numArgs)
+		copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+		result, err := callee.Fn(vm.env, args...)
+		if err != nil {
+			return Evaluator.BuiltinError(info.Position, callee.Name, err)
+		}
+		vm.sp = vm.sp - numArgs - 1
+		vm.push(result)
+	default:
+		return Evaluator.UnknownFunctionError(info.Position, info.Name)
+	}
+	return nil
+}
+
+// pushBound pushes the value loaded by a get instruction, failing as Eval does when
+// the name has not been bound yet.
+func (vm *VM) pushBound(info Compiler.SourceInfo, value Object.Object) error {
+	if value != nil {
+		vm.push(value)
+		return nil
+	}
+
+	switch {
+	case info.Callee:
+		return Evaluator.UnknownFunctionError(info.Position, info.Name)
+	case info.Assign:
+		return Evaluator.UndeclaredAssignmentError(info.Position, info.Name)
+	}
+	return Evaluator.UnknownIdentifierError(info.Position, info.Name)
+}
+
+// cell returns the cell of a boxed local, boxing the value in its slot the first
+// time, which is how boxed parameters get their cell.
+func (vm *VM) cell(frame *Frame, index int) *Cell {
+	slot := frame.basePointer + index
+	if cell, ok := vm.stack[slot].(*Cell); ok {
+		return cell
+	}
+	cell := &Cell{Value: vm.stack[slot]}
+	vm.stack[slot] = cell
+	return cell
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex < len(vm.frames) {
+		vm.frames[vm.framesIndex] = f
+	} else {
+		vm.frames = append(vm.frames, f)
+	}
+	vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// reserve grows the stack so that it holds at least size values.
+func (vm *VM) reserve(size int) {
+	for size > len(vm.stack) {
+		vm.stack = append(vm.stack, make([]Object.Object, len(vm.stack))...)
+	}
+}
+
+func (vm *VM) push(o Object.Object) {
+	vm.reserve(vm.sp + 1)
+	vm.stack[vm.sp] = o
+	vm.sp++
+}
+
+func (vm *VM) pop() Object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
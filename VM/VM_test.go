@@ -0,0 +1,428 @@
+package VM
+
+import (
+	"Chimp/Ast"
+	"Chimp/Compiler"
+	"Chimp/Evaluator"
+	"Chimp/Lexer"
+	"Chimp/Object"
+	"Chimp/Parser"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunMatchesEval(t *testing.T) {
+	tests := []string{
+		"5",
+		"true",
+		"false",
+		`"hello"`,
+		`"hello " + "world"`,
+		"5 + 1",
+		"5 - 2",
+		"5 * 2 + 2",
+		"10 / 2 + 2",
+		"-10",
+		"!true",
+		"5 > 1",
+		"1 >= 1",
+		"5 < 1",
+		"5 <= 1",
+		"5 == 6",
+		`"a" != "b"`,
+		`"a" + "b" == "ab"`,
+		"monkeySay foo = 5; foo",
+		"monkeySay foo = 5;foo + 1",
+		"",
+		"if (1 < 3) { return 5 }",
+		"if (2 != 1 + 1) { return 3 } else { return 10 }",
+		"if (1 > 2) { 5 }",
+		"return 10; 9",
+		"1; return 2 * 5; 9",
+		"if (1 < 2) { if (2 < 3) { return 10 } return 1 } return 2",
+		"monkeySay f = monkeyDo(x) { if (x > 1) { return 1; } return 2; }; f(5)",
+		"monkeySay f = monkeyDo(x) { if (x > 1) { return 1; } return 2; }; f(0)",
+		"monkeySay f = monkeyDo() { return 1; }; f() + 5",
+		`monkeySay outer = monkeyDo() {
+			monkeySay inner = monkeyDo() { return 1; };
+			inner();
+			return 3;
+		};
+		outer()`,
+		"monkeyDo(x ,y) { return 5; }",
+		"(monkeyDo() { })()",
+		"(monkeyDo() { return 5; })()",
+		"(monkeyDo(x, y) { return y * x; })(5, 3)",
+		"monkeySay x = 5; (monkeyDo(x) { return x; })(10); x; ",
+		"monkeySay closure = monkeyDo(x) { return monkeyDo() { return x } } closure(5)();",
+		`monkeySay pair = monkeyDo(x, y) { return monkeyDo(i) { if (i == 0) { return x } else { return y } } }
+		monkeySay list = pair(100, pair(100, pair(0, 0) ) );
+		monkeySay sum = monkeyDo(l) { if (l(0) == 0) { return 0 } else { return (sum(l(1))) + l(0) } };
+		sum(list)`,
+		"monkeySay f = monkeyDo(x) { monkeySay y = x * 2; monkeyDo(z) { x + y + z } }; f(1)(10)",
+		"monkeySay f = monkeyDo() { g() }; monkeySay g = monkeyDo() { 7 }; f()",
+		"[1, 2 * 2, 3 + 3]",
+		"[]",
+		"rest([1, 2, 3])",
+		"monkeySay a = [1]; push(a, 2); a",
+		"[[1, 2], [3]][0][1]",
+		"monkeySay a = [1, 2, 3]; a[0] + a[2]",
+		`len("four")`,
+		"last([7, 8])",
+		"monkeySay sum = monkeyDo(a) { if (len(a) == 0) { return 0 } return first(a) + sum(rest(a)) }; sum([1, 2, 3, 4])",
+		`{"b": 2, "a": 1}`,
+		`{1: true, true: "yes", "1": 1}`,
+		`monkeySay k = "key"; {k: 1 + 1}`,
+		`{"a": 1, "a": 2}`,
+		"monkeySay m = {}; m",
+		`{1: 10, true: 20}[true]`,
+		`monkeySay m = {"x": [1, 2, 3]}; m["x"][2]`,
+		`{"a": 1}["b"]`,
+		"type(monkeyDo() {})",
+		"type(len)",
+		"monkeySay i = 0; while (i < 10) { monkeySay i = i + 1 }; i",
+		"monkeySay sum = 0; for (monkeySay i = 1; i <= 4; monkeySay i = i + 1) { sum = sum + i }; sum",
+		"monkeySay i = 0; for (;;) { i = i + 1; if (i > 5) { break } }; i",
+		"monkeySay odd = 0; for (monkeySay i = 0; i < 10; monkeySay i = i + 1) { if (i < 5) { continue } odd = odd + 1 }; odd",
+		`monkeySay count = 0;
+		for (monkeySay i = 0; i < 3; monkeySay i = i + 1) {
+			for (monkeySay j = 0; j < 3; monkeySay j = j + 1) { if (j == 1) { break } count = count + 1 }
+		};
+		count`,
+		`monkeySay find = monkeyDo(a, x) {
+			for (monkeySay i = 0; i < len(a); monkeySay i = i + 1) { if (a[i] == x) { return i } }
+			return 0 - 1
+		};
+		find([4, 5, 6], 6) + find([4], 6)`,
+		"monkeySay f = monkeyDo() { while (true) { if (true) { return 7 } } }; f()",
+		"while (false) { 1 }",
+		"monkeySay x = 1; x = x + 1; x",
+		"monkeySay x = 1; monkeySay y = 2; x = y = 7; x + y",
+		"monkeySay x = 10; x += 5; x -= 3; x *= 2; x /= 4; x",
+		"monkeySay x = 1; x++",
+		"monkeySay x = 1; x--; --x",
+		`monkeySay s = "a"; s += "b"; s`,
+		"monkeySay x = 1; monkeySay f = monkeyDo() { x = 2 }; f(); x",
+		"monkeySay x = 1; monkeySay f = monkeyDo(x) { x = 2 }; f(0); x",
+		"monkeySay x = 1; monkeySay f = monkeyDo() { monkeySay x = 5; x = 2 }; f(); x",
+		"monkeySay counter = monkeyDo() { monkeySay count = 0; monkeyDo() { count++; count } }; monkeySay next = counter(); next(); next(); next()",
+		"monkeySay f = monkeyDo() { monkeySay x = 1; monkeySay get = monkeyDo() { x }; x = 10; get() }; f()",
+		"monkeySay f = monkeyDo(n) { monkeyDo() { monkeyDo() { n += 1 }() + n } }; f(1)()",
+		"monkeySay f = monkeyDo() { monkeySay c = 0; monkeySay inc = monkeyDo() { c++ }; inc(); inc(); c }; f()",
+		"monkeySay outer = monkeyDo() { monkeySay f = monkeyDo() { g() }; monkeySay g = monkeyDo() { 1 }; f() }; outer()",
+		`monkeySay outer = monkeyDo(x) {
+			monkeySay even = monkeyDo(n) { if (n == 0) { return true } odd(n - 1) };
+			monkeySay odd = monkeyDo(n) { if (n == 0) { return false } even(n - 1) };
+			[even(x), odd(x)]
+		};
+		outer(7)`,
+		"monkeySay f = monkeyDo() { f = 2 }; f()",
+		"monkeySay f = monkeyDo() { f = 2 }; f(); f",
+		"monkeySay f = monkeyDo() { monkeyDo() { f = 1; f++ }() }; f(); f",
+		"monkeySay g = monkeyDo() { monkeySay f = monkeyDo() { f = 3 }; f(); f }; g()",
+		"monkeySay g = monkeyDo() { monkeySay f = monkeyDo(n) { if (n > 0) { return f(n - 1) } f = n }; f(2); f }; g()",
+		`monkeySay f = monkeyDo() {
+			monkeySay fs = [];
+			for (monkeySay i = 0; i < 3; i++) { fs = push(fs, monkeyDo() { i }) }
+			fs[0]() + fs[2]()
+		};
+		f()`,
+		"monkeySay sum = 0; for (monkeySay i = 0; i < 5; i++) { sum += i }; sum",
+		"monkeySay i = 10; for (monkeySay i = 0; i < 3; i++) { }; i",
+		"monkeySay f = monkeyDo() { monkeySay i = 10; for (monkeySay i = 0; i < 3; i++) { monkeySay j = i }; i }; f()",
+		"monkeySay f = monkeyDo() { monkeySay n = 0; for (monkeySay i = 0; i < 3; i++) { for (monkeySay i = 0; i < 2; i++) { n++ } }; n }; f()",
+		"true && false",
+		"false || true",
+		"1 < 2 && 2 < 3 || false",
+		"!(1 > 2) && true",
+		"false && missing",
+		"true || missing",
+		"monkeySay calls = 0; monkeySay f = monkeyDo() { calls++; true }; false && f(); true || f(); calls",
+		"monkeySay calls = 0; monkeySay f = monkeyDo() { calls++; true }; true && f(); false || f(); calls",
+		"1 - 2 * 3 + 4",
+		"true == true",
+		"true != false",
+		`1 == "1"`,
+		"[1, [2, 3]] == [1, [2, 3]]",
+		`{"a": 1, 2: [3]} == {2: [3], "a": 1}`,
+		"monkeySay f = monkeyDo() { 1 }; monkeySay g = f; f == g",
+		"monkeyDo() { 1 } == monkeyDo() { 1 }",
+		"monkeySay make = monkeyDo() { monkeyDo() { 1 } }; make() == make()",
+		"len == len",
+		"(monkeyDo() {})() == (monkeyDo() {})()",
+		`"a" < "b"`,
+		"monkeySay len = monkeyDo(x) { 0 }; len([1])",
+		"9223372036854775807 + 1",
+		"[1.5, 2.0, 1e21, 0.1 + 0.2, 1 + 0.5, 3 / 2.0, -2.5 * 2, 0xff + 0b1 + 0o7 + 1_000]",
+		"[1 == 1.0, 2 < 2.5, 2.5 >= 3, !0.0, 9223372036854775808 * 0.5]",
+		"monkeySay x = 1; x += 0.5; x",
+		"monkeySay min = -9223372036854775807 - 1; [-min, min / -1, min * -1, min - 1]",
+		"123456789012345678901234567890 * 3",
+		"monkeySay fact = monkeyDo(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)",
+		"(9223372036854775807 + 10) - 20",
+		"(9223372036854775807 + 1) == 9223372036854775808",
+		"monkeySay x = 9223372036854775807; x++; x",
+		"if (1) { 1 }",
+		"if (0) { 1 }",
+		`if ("") { 1 } else { 2 }`,
+		"monkeySay i = 3; monkeySay n = 0; while (i) { i--; n++ }; n",
+		"monkeySay n = 0; for (monkeySay xs = [1, 2]; xs; xs = rest(xs)) { n++ }; n",
+		"!0",
+		"![]",
+		"!{}",
+		"!len",
+		"1 && true",
+		"true && 1",
+		`false || "yes"`,
+		"0 || [] || 5",
+		"monkeySay a = 3; monkeySay b = 7; monkeySay x = if (a > b) { a } else { b }; x",
+		"1 + if (true) { 2 } else { 3 } * 2",
+		"monkeySay abs = monkeyDo(n) { if (n < 0) { -n } else { n } }; abs(-4)",
+		"monkeySay x = if (false) { 1 }; x",
+		"monkeySay x = 5; if (x < 3) { 1 } else if (x < 6) { 2 } else { 3 }",
+		"monkeySay x = 9; if (x < 3) { 1 } else if (x < 6) { 2 }",
+		"match (2) { 1 => 10, 2 => 20, _ => 30 }",
+		"match (3) { 1 => 10, 2 => 20, _ => 30 }",
+		"match (-1) { -1 => 5, _ => 6 }",
+		`match ("b") { "a" => 1, "b" => 2 }`,
+		"match (4) { 1 => 0, n => n * 2 }",
+		"monkeySay n = 1; match (4) { n => n }; n",
+		"monkeySay f = monkeyDo() { monkeySay n = 1; match (4) { n => n }; n }; f()",
+		"monkeySay f = monkeyDo(v) { match (v) { 4 => { monkeySay n = 2; monkeyDo() { n } }, n => monkeyDo() { n } } }; f(4)() + f(5)()",
+		"match (1) { 1 => { monkeySay y = 3; y + 1 }, _ => 0 }",
+		"match ([1]) { 1 => 1, _ => 2 }",
+		"monkeySay f = monkeyDo(n) { match (n) { 0 => { return 100 }, _ => n } }; f(0) + f(5)",
+		"monkeySay fib = monkeyDo(n) { match (n) { 0 => 0, 1 => 1, _ => fib(n - 1) + fib(n - 2) } }; fib(10)",
+		"monkeySay f = monkeyDo(v) { match (v) { n => monkeyDo() { n } } }; f(7)()",
+		"monkeySay n = 0; for (monkeySay i = 0; i < 6; i++) { n += match (i) { 2 => { continue }, 4 => { break }, _ => i } }; n",
+		"monkeySay n = 0; while (true) { n++; while (if (n > 2) { break } else { false }) { } }; n",
+		"monkeySay f = monkeyDo(x) { [1, if (x) { return 5 } else { 2 }] }; f(true)",
+		"monkeySay f = monkeyDo(x) { len([1, if (x) { return 5 } else { 2 }]) }; f(false)",
+		"monkeySay f = monkeyDo(x) { -(match (x) { 1 => { return 9 }, _ => 3 }) }; f(1) + f(2)",
+		"monkeySay n = 0; while (true) { monkeySay step = if (n > 2) { break } else { 1 }; n += step }; n",
+	}
+
+	for _, input := range tests {
+		expected, err := Evaluator.Eval(parse(t, input), Object.NewEnvironment(nil))
+		if err != nil {
+			t.Fatalf("unexpected Eval error for %q: %s", input, err)
+		}
+
+		vm, err := run(input, nil)
+		if err != nil {
+			t.Fatalf("unexpected VM error for %q: %s", input, err)
+		}
+
+		if vm.Result().Type() != expected.Type() || vm.Result().Inspect() != expected.Inspect() {
+			t.Errorf("result of %q didn't match Eval, expected: %s %s, got: %s %s",
+				input, expected.Type(), expected.Inspect(), vm.Result().Type(), vm.Result().Inspect())
+		}
+	}
+}
+
+func TestRunErrorsMatchEval(t *testing.T) {
+	tests := []string{
+		"varThatDoesntExist",
+		"badFunc(10)",
+		"1 + true",
+		"true < false",
+		"[1] >= [1]",
+		"monkeyDo() { 1 } > len",
+		`"a" - "b"`,
+		"monkeySay a = 1;\n  missing",
+		"monkeySay x = missing; x",
+		"missing; 1",
+		"-true",
+		"(1 + missing) * 2",
+		"if (missing) { 1 }",
+		"0 || missing",
+		"1 / 0",
+		"1 / 0.0",
+		"{1.5: 1}",
+		"monkeySay x = 5; x /= 0",
+		"match (3) { 1 => 1, 2 => 2 }",
+		`monkeySay x = 1; x + match ("a") { "b" => 1 }`,
+		"match (1) { 1 => missing }",
+		"monkeySay f = monkeyDo(v) { match (v) { 1 => { monkeySay n = 1 }, _ => 0 }; n }; f(2)",
+		"monkeySay x = if (true) { missing }; x",
+		"x = 1",
+		"len = 1",
+		"x += missing",
+		"monkeySay x = 1; x = missing",
+		"monkeyDo() { y++ }()",
+		"monkeySay b = true; b++",
+		"monkeySay f = monkeyDo() { if (false) { monkeySay x = 1 } x }; f()",
+		"for (monkeySay i = 0; i < 3; i++) { }; i",
+		"match (4) { n => n }; n",
+		"monkeySay f = monkeyDo() { match (4) { 4 => { monkeySay n = 2 } }; n }; f()",
+		"for (monkeySay i = 0; i < 3; i++) { monkeySay final = i }; final",
+		"monkeySay f = monkeyDo() { for (monkeySay i = 0; i < 3; i++) { monkeySay j = i }; j }; f()",
+		"monkeySay f = monkeyDo() { if (false) { monkeySay x = 1 } monkeyDo() { x }() }; f()",
+		"monkeySay f = monkeyDo(x) { x + true }; f(1)",
+		"monkeySay f = monkeyDo(x) { x }; f(missing)",
+		"monkeySay f = monkeyDo(x) { x }; f(1, 2)",
+		"monkeySay f = monkeyDo(x) { return missing }; f(1) + 1",
+		"monkeySay f = monkeyDo() { monkeyDo() { missing } }; f()()",
+		"monkeySay x = 1; x(2)",
+		"[1, 2, 3][3]",
+		"[1, 2, 3][true]",
+		"5[0]",
+		"[missing]",
+		"first([])",
+		"len(1)",
+		"push([1])",
+		"monkeySay m = {[1]: 2}",
+		`{"a": 1}[[1]]`,
+	}
+
+	for _, input := range tests {
+		_, expected := Evaluator.Eval(parse(t, input), Object.NewEnvironment(nil))
+		if expected == nil {
+			t.Fatalf("expected an Eval error for %q", input)
+		}
+
+		_, err := run(input, nil)
+		if err == nil {
+			t.Fatalf("expected a VM error for %q", input)
+		}
+
+		if err.Error() != expected.Error() {
+			t.Errorf("error of %q didn't match Eval, expected: '%s', got: '%s'", input, expected, err)
+		}
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`puts("hello", 1 + 2)`, "hello\n3\n"},
+		{`print("a", [1, 2], true); print("!")`, "a [1, 2] true!"},
+		{`monkeySay say = monkeyDo(x) { puts(x) }; say("from a function")`, "from a function\n"},
+	}
+
+	for _, tt := range tests {
+		out := bytes.Buffer{}
+		if _, err := run(tt.input, &out); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("output didn't match, expected: %q, got: %q", tt.expected, out.String())
+		}
+	}
+}
+
+func TestRunCallDepth(t *testing.T) {
+	_, err := run("monkeySay f = monkeyDo(x) { f(x + 1) }; f(0)", nil)
+
+	if !errors.Is(err, Evaluator.ErrCallDepthLimitExceeded) {
+		t.Fatalf("expected the call depth limit, got %v", err)
+	}
+}
+
+func TestRunLoopJumpsDropOperands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"monkeySay n = 0; for (monkeySay i = 0; i < 100; i++) { n = 1 + if (i > 50) { break } else { n } }; n", "51"},
+		{"monkeySay n = 0; for (monkeySay i = 0; i < 100; i++) { puts(if (i < 50) { continue } else { i }); n++ }; n", "50"},
+		{"monkeySay n = 0; while (n < 100) { n++; [1, {\"a\": n}[if (n > 1) { continue } else { \"a\" }]] }; n", "100"},
+		{"monkeySay n = 0; for (;;) { n += if (n > 9) { break } else { 1 }; }; n", "10"},
+	}
+
+	baseline, err := run("1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tt := range tests {
+		vm, err := run(tt.input, &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", tt.input, err)
+		}
+		if vm.Result().Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, vm.Result().Inspect())
+		}
+		if vm.sp != baseline.sp {
+			t.Errorf("%q: left %d values on the stack, expected %d", tt.input, vm.sp, baseline.sp)
+		}
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   Evaluator.Limits
+		expected error
+	}{
+		{"while (true) { }", Evaluator.Limits{MaxSteps: 1000}, Evaluator.ErrStepLimitExceeded},
+		{"monkeySay i = 0; while (i < 10) { i++ }; i", Evaluator.Limits{MaxSteps: 1000}, nil},
+		{"monkeySay f = monkeyDo(n) { if (n > 0) { f(n - 1) } else { 0 } }; f(20)", Evaluator.Limits{MaxCallDepth: 10}, Evaluator.ErrCallDepthLimitExceeded},
+		{"monkeySay f = monkeyDo(n) { if (n > 0) { f(n - 1) } else { 0 } }; f(9)", Evaluator.Limits{MaxCallDepth: 10}, nil},
+		{"monkeySay f = monkeyDo(n) { if (n > 0) { f(n - 1) } else { 0 } }; f(20000)", Evaluator.Limits{}, nil},
+	}
+
+	for _, tt := range tests {
+		compiler := Compiler.New()
+		if err := compiler.Compile(parse(t, tt.input)); err != nil {
+			t.Fatalf("unexpected compile error: %s", err)
+		}
+
+		err := New(compiler.Bytecode()).RunContext(context.Background(), tt.limits)
+
+		if tt.expected == nil && err != nil || !errors.Is(err, tt.expected) {
+			t.Errorf("%q within %+v: expected %v, got %v", tt.input, tt.limits, tt.expected, err)
+		}
+	}
+}
+
+func TestRunDeadline(t *testing.T) {
+	input := `monkeySay fib = monkeyDo(n) { if (n < 2) { return n } return fib(n - 1) + fib(n - 2) }; fib(40)`
+
+	compiler := Compiler.New()
+	if err := compiler.Compile(parse(t, input)); err != nil {
+		t.Fatalf("unexpected compile error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := New(compiler.Bytecode()).RunContext(ctx, Evaluator.DefaultLimits)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to stop the VM, got %v", err)
+	}
+}
+
+func parse(t *testing.T, input string) Ast.Programme {
+	l := Lexer.New(input)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+	if len(p.GetErrors()) > 0 {
+		t.Fatalf("parse errors for %q: %v", input, p.GetErrors())
+	}
+	return programme
+}
+
+func run(input string, out *bytes.Buffer) (*VM, error) {
+	l := Lexer.New(input)
+	p := Parser.New(*l)
+	programme := p.ParseProgramme()
+
+	compiler := Compiler.New()
+	if err := compiler.Compile(programme); err != nil {
+		return nil, err
+	}
+
+	vm := New(compiler.Bytecode())
+	if out != nil {
+		vm.SetOutput(out)
+	}
+	return vm, vm.Run()
+}
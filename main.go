@@ -1,18 +1,114 @@
 package main
 
 import (
+	"Chimp/Interpreter"
 	"Chimp/Repl"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
+	"strings"
 )
 
+const usage = `usage: chimp [run] <file> [arguments...]
+
+Runs the script in file, passing it the arguments through the args builtin.
+Without a file, chimp starts an interactive session, or runs the programme
+piped through standard input. On Linux and macOS the session edits lines as
+they are typed, with a history and completion; elsewhere it reads plain lines.
+`
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run carries out the command line in args and returns the exit status: 0 on
+// success, 1 when the script fails and 2 when the command line is malformed.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		if !isTerminal(stdin) {
+			return runStream(stdin, stdout, stderr)
+		}
+		startRepl(stdin, stdout)
+		return 0
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	case "run":
+		args = args[1:]
+		if len(args) == 0 {
+			_, _ = fmt.Fprint(stderr, usage)
+			return 2
+		}
+	}
+	// there are no options besides the help, so anything else that looks like one
+	// is a mistake rather than the name of a script
+	if strings.HasPrefix(args[0], "-") {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	return runFile(args[0], args[1:], stdout, stderr)
+}
+
+// runFile runs the script at path, writing its errors to stderr.
+func runFile(path string, args []string, stdout io.Writer, stderr io.Writer) int {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "chimp: %s\n", err)
+		return 1
+	}
+	return runSource(path, string(source), args, stdout, stderr)
+}
+
+// runStream runs the whole of in as a single programme, without prompts or colour.
+func runStream(in io.Reader, stdout io.Writer, stderr io.Writer) int {
+	source, err := io.ReadAll(in)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "chimp: %s\n", err)
+		return 1
+	}
+	return runSource("<stdin>", string(source), nil, stdout, stderr)
+}
+
+// runSource runs source under the file name used in its error positions.
+func runSource(fileName string, source string, args []string, stdout io.Writer, stderr io.Writer) int {
+	interpreter := Interpreter.New()
+	interpreter.SetOutput(stdout)
+	interpreter.SetArgs(args)
+
+	_, err := interpreter.RunFile(fileName, source)
+	if parseError, ok := err.(Interpreter.ParseError); ok {
+		for _, message := range parseError.Errors {
+			_, _ = fmt.Fprintln(stderr, message)
+		}
+		return 1
+	} else if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func startRepl(stdin io.Reader, stdout io.Writer) {
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-	Repl.Start(os.Stdin, os.Stdout)
+	_, _ = fmt.Fprintf(stdout, "Hello %s! This is the Monkey programming language!\n", user.Username)
+	_, _ = fmt.Fprintf(stdout, "Feel free to type in commands, or :help for the REPL commands\n")
+	Repl.Start(stdin, stdout)
+}
+
+// isTerminal reports whether in is a character device, as an interactive stdin is.
+func isTerminal(in io.Reader) bool {
+	file, ok := in.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
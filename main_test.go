@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{"ok", "puts(1 + 2)", nil, 0, "3\n", ""},
+		{"shebang", "#!/usr/bin/env chimp\nputs(\"hi\")", nil, 0, "hi\n", ""},
+		{"args", "puts(len(args())); puts(args()[1])", []string{"a", "b"}, 0, "2\nb\n", ""},
+		{"no args", "puts(args())", nil, 0, "[]\n", ""},
+		{"flag-like args", "puts(args())", []string{"-x", "--verbose"}, 0, "[-x, --verbose]\n", ""},
+		{"parse error", "#!/usr/bin/env chimp\nmonkeySay = 1;\nmonkeySay 2", nil, 1, "",
+			"%s:2:11: expected IDENT, but received '='\n%s:3:11: expected IDENT, but received '2'\n"},
+		{"runtime error", "puts(1);\n  missing", nil, 1, "1\n", "%s:2:3: Cannot find indentifier 'missing'.\n"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name+".chimp")
+		if err := os.WriteFile(path, []byte(tt.source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, command := range [][]string{{"run", path}, {path}} {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			status := run(append(command, tt.args...), strings.NewReader(""), stdout, stderr)
+
+			expectedStderr := strings.ReplaceAll(tt.stderr, "%s", path)
+			if status != tt.status || stdout.String() != tt.stdout || stderr.String() != expectedStderr {
+				t.Errorf("%s %v: expected status %d, stdout %q and stderr %q, got %d, %q and %q",
+					tt.name, command, tt.status, tt.stdout, expectedStderr, status, stdout.String(), stderr.String())
+			}
+		}
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := run([]string{"run"}, strings.NewReader(""), stdout, stderr); status != 2 || stderr.String() != usage {
+		t.Errorf("expected the usage with status 2, got %d and %q", status, stderr.String())
+	}
+
+	for _, args := range [][]string{{"-x"}, {"--verbose", "script.chimp"}, {"run", "-x"}} {
+		stderr.Reset()
+		if status := run(args, strings.NewReader(""), stdout, stderr); status != 2 || stderr.String() != usage {
+			t.Errorf("%v: expected the usage with status 2, got %d and %q", args, status, stderr.String())
+		}
+	}
+
+	stderr.Reset()
+	if status := run([]string{"missing.chimp"}, strings.NewReader(""), stdout, stderr); status != 1 || !strings.HasPrefix(stderr.String(), "chimp: ") {
+		t.Errorf("expected an error for a missing file, got %d and %q", status, stderr.String())
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	tests := []struct {
+		input  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{"monkeySay add = monkeyDo(a, b) {\n  a + b\n};\nputs(add(1, 2))\n", 0, "3\n", ""},
+		{"1 + 2", 0, "", ""},
+		{"puts(\"a\");\nmissing", 1, "a\n", "<stdin>:2:1: Cannot find indentifier 'missing'.\n"},
+	}
+
+	for _, tt := range tests {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := run(nil, strings.NewReader(tt.input), stdout, stderr)
+
+		if status != tt.status || stdout.String() != tt.stdout || stderr.String() != tt.stderr {
+			t.Errorf("%q: expected status %d, stdout %q and stderr %q, got %d, %q and %q",
+				tt.input, tt.status, tt.stdout, tt.stderr, status, stdout.String(), stderr.String())
+		}
+	}
+}